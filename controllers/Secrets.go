@@ -3,115 +3,84 @@ package controllers
 import (
 	ctx "context"
 	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
-	"time"
+	"sort"
 
 	"github.com/kube-carbonara/cluster-agent/models"
 	services "github.com/kube-carbonara/cluster-agent/services"
 	utils "github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type SecretsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
-func (c SecretsController) runWatcherEventLoop() error {
-	config := utils.NewConfig()
-	var client utils.Client = *utils.NewClient()
-	watch, err := client.Clientset.CoreV1().Secrets(v1.NamespaceAll).Watch(ctx.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Fatal(err.Error())
-		return err
-	}
-
-	channel := watch.ResultChan()
-
-	done := make(chan struct{})
-
-	session := utils.Session{
-		Host:    config.RemoteProxy,
-		Channel: "monitoring",
-	}
-	session.NewSession()
-	defer session.Conn.Close()
-	defer close(done)
-	for {
-		select {
-		case event, ok := <-channel:
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-			obj, ok := event.Object.(*v1.Secret)
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-
-			err := services.MonitoringService{
-				NameSpace: obj.Namespace,
-				EventName: string(event.Type),
-				Resource:  utils.RESOUCETYPE_SECRETS,
-				PayLoad:   obj,
-			}.PushEvent(&session)
-
-			if err != nil {
-				logrus.Error(err)
-				session.Conn.Close()
-				session = *session.NewSession()
-				services.MonitoringService{
-					EventName: string(event.Type),
-					Resource:  utils.RESOUCETYPE_SECRETS,
-					PayLoad:   obj,
-				}.PushEvent(&session)
-			}
-
-		case <-time.After(30 * time.Minute):
-			logrus.Info("Timeout, restarting event watcher")
-			return nil
-
-		}
-	}
-
-}
-
-func (c SecretsController) Watch() {
-
-	for {
-		if err := c.runWatcherEventLoop(); err != nil {
-			logrus.Error(err)
-		}
-
-	}
+func (c SecretsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Core().V1().Secrets().Informer(), utils.RESOUCETYPE_SECRETS, buffer)
 }
 
 func (c SecretsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Secrets(nameSpaceName).Get(ctx.TODO(), name, metav1.GetOptions{})
+	reveal, err := c.reveal(context)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
+		return context.JSON(http.StatusForbidden, models.Response{
 			Message: err.Error(),
 		})
 	}
+	var result *v1.Secret
+	if reveal {
+		// values are read as the end user, so cluster RBAC applies
+		client, err := userClient(context, c.Client)
+		if err != nil {
+			return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+		}
+		result, err = client.Clientset.CoreV1().Secrets(nameSpaceName).Get(context.Request().Context(), name, metav1.GetOptions{})
+		if err != nil {
+			return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+		}
+	} else {
+		result, err = c.Cache.Factory.Core().V1().Secrets().Lister().Secrets(nameSpaceName).Get(name)
+		if err != nil {
+			return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+		}
+	}
 
 	return context.JSON(http.StatusOK, models.Response{
-		Data:         utils.StructToMap(result),
+		Data:         utils.StructToMap(c.present(result, reveal)),
 		ResourceType: utils.RESOUCETYPE_SECRETS,
 	})
 }
 
 func (c SecretsController) Get(context echo.Context, nameSpaceName string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Secrets(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{})
+	reveal, err := c.reveal(context)
+	if err != nil {
+		return context.JSON(http.StatusForbidden, models.Response{
+			Message: err.Error(),
+		})
+	}
+	query, err := parseListQuery(context, &v1.Secret{})
 	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
 			Message: err.Error(),
 		})
 	}
+	var secrets []*v1.Secret
+	if reveal {
+		secrets, err = c.listAsUser(context, nameSpaceName, query)
+	} else {
+		secrets, err = c.Cache.Factory.Core().V1().Secrets().Lister().Secrets(nameSpaceName).List(query.labelSelector)
+	}
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+	}
+	result := c.toList(secrets, reveal)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+	}
 
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(result),
@@ -128,16 +97,17 @@ func (c SecretsController) Create(context echo.Context, nameSpaceName string, se
 		})
 	}
 
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+	}
 	result, err := client.Clientset.CoreV1().Secrets(nameSpaceName).Create(ctx.TODO(), secret, metav1.CreateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
-		Data:         utils.StructToMap(result),
+		Data:         utils.StructToMap(c.present(result, false)),
 		ResourceType: utils.RESOUCETYPE_SECRETS,
 	})
 }
@@ -151,27 +121,29 @@ func (c SecretsController) Update(context echo.Context, nameSpaceName string, se
 		})
 	}
 
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+	}
 	result, err := client.Clientset.CoreV1().Secrets(nameSpaceName).Update(ctx.TODO(), secret, metav1.UpdateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
-		Data:         utils.StructToMap(result),
+		Data:         utils.StructToMap(c.present(result, false)),
 		ResourceType: utils.RESOUCETYPE_SECRETS,
 	})
 }
 
 func (c SecretsController) Delete(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	err := client.Clientset.CoreV1().Secrets(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
+	}
+	err = client.Clientset.CoreV1().Secrets(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SECRETS)
 	}
 
 	return context.JSON(http.StatusNoContent, models.Response{
@@ -179,3 +151,58 @@ func (c SecretsController) Delete(context echo.Context, nameSpaceName string, na
 		ResourceType: utils.RESOUCETYPE_SECRETS,
 	})
 }
+
+func (c SecretsController) toList(secrets []*v1.Secret, reveal bool) *v1.SecretList {
+	list := &v1.SecretList{
+		Items: make([]v1.Secret, 0, len(secrets)),
+	}
+	for _, item := range secrets {
+		list.Items = append(list.Items, *c.present(item, reveal))
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
+
+// listAsUser lists the secrets as the end user, so cluster RBAC applies
+// to the values revealed.
+func (c SecretsController) listAsUser(context echo.Context, nameSpaceName string, query listQuery) ([]*v1.Secret, error) {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.Clientset.CoreV1().Secrets(nameSpaceName).List(context.Request().Context(), metav1.ListOptions{
+		LabelSelector: query.labelSelector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]*v1.Secret, 0, len(list.Items))
+	for i := range list.Items {
+		secrets = append(secrets, &list.Items[i])
+	}
+	return secrets, nil
+}
+
+// reveal tells whether the request opted in to see secret values with
+// `reveal=true`. Opting in without the reveal key is refused; the RBAC
+// policy also requires the reveal verb on secrets.
+func (c SecretsController) reveal(context echo.Context) (bool, error) {
+	if context.QueryParam("reveal") != "true" {
+		return false, nil
+	}
+	if !utils.CanRevealSecrets(context.Request().Header.Get("x-agent-reveal-key")) {
+		return false, errors.New("revealing secret values is not permitted")
+	}
+	return true, nil
+}
+
+// present applies the redaction policy: secrets are metadata-only unless
+// revealing was explicitly permitted for the request.
+func (c SecretsController) present(secret *v1.Secret, reveal bool) *v1.Secret {
+	if reveal {
+		return secret
+	}
+	return utils.RedactSecret(secret)
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"github.com/kube-carbonara/cluster-agent/middlewares"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// auditEntry returns the log entry interactive sessions (exec, attach,
+// port-forward) are audited with. It names the caller as authenticated by
+// the middleware along with the target of the session.
+func auditEntry(context echo.Context, action string, fields logrus.Fields) *logrus.Entry {
+	entry := logrus.WithFields(fields).WithFields(logrus.Fields{
+		"audit":  action,
+		"remote": context.RealIP(),
+	})
+	if identity := middlewares.CurrentIdentity(context); identity != nil {
+		entry = entry.WithFields(logrus.Fields{
+			"subject": identity.Subject,
+			"role":    identity.Role,
+			"user":    identity.User,
+			"groups":  identity.Groups,
+		})
+	}
+	return entry
+}
@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+type ConfigMapsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c ConfigMapsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Core().V1().ConfigMaps().Informer(), utils.RESOUCETYPE_CONFIGMAPS, buffer)
+}
+
+func (c ConfigMapsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Cache.Factory.Core().V1().ConfigMaps().Lister().ConfigMaps(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CONFIGMAPS,
+	})
+}
+
+func (c ConfigMapsController) Get(context echo.Context, nameSpaceName string) error {
+	query, err := parseListQuery(context, &v1.ConfigMap{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	configMaps, err := c.Cache.Factory.Core().V1().ConfigMaps().Lister().ConfigMaps(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	result := c.toList(configMaps)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CONFIGMAPS,
+	})
+}
+
+func (c ConfigMapsController) Create(context echo.Context, nameSpaceName string, configMapConfig map[string]interface{}) error {
+	configMap := &v1.ConfigMap{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(configMapConfig), configMap)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	return c.create(context, nameSpaceName, configMap)
+}
+
+// CreateFromFiles creates the config map named by the name form field from
+// a multipart upload, like kubectl create configmap --from-file. Every
+// uploaded file becomes a key named after the file; files which are not
+// valid UTF-8 go to binaryData.
+func (c ConfigMapsController) CreateFromFiles(context echo.Context, nameSpaceName string) error {
+	form, err := context.MultipartForm()
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	configMap, err := configMapFromForm(form)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	return c.create(context, nameSpaceName, configMap)
+}
+
+func (c ConfigMapsController) create(context echo.Context, nameSpaceName string, configMap *v1.ConfigMap) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	result, err := client.Clientset.CoreV1().ConfigMaps(nameSpaceName).Create(ctx.TODO(), configMap, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CONFIGMAPS,
+	})
+}
+
+func (c ConfigMapsController) Update(context echo.Context, nameSpaceName string, configMapConfig map[string]interface{}) error {
+	configMap := &v1.ConfigMap{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(configMapConfig), configMap)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	result, err := client.Clientset.CoreV1().ConfigMaps(nameSpaceName).Update(ctx.TODO(), configMap, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CONFIGMAPS,
+	})
+}
+
+func (c ConfigMapsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	err = client.Clientset.CoreV1().ConfigMaps(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: utils.RESOUCETYPE_CONFIGMAPS,
+	})
+}
+
+// References lists the pods and the workloads whose pod template use the
+// config map, through a volume or environment variables. The config map
+// does not have to exist, so dangling references can be found too.
+func (c ConfigMapsController) References(context echo.Context, nameSpaceName string, name string) error {
+	result := &models.ConfigMapReferenceList{
+		ConfigMap: name,
+		NameSpace: nameSpaceName,
+		Items:     make([]models.ConfigMapReference, 0),
+	}
+	add := func(kind string, object metav1.Object, spec *v1.PodSpec) {
+		if usages := configMapUsages(spec, name); len(usages) > 0 {
+			result.Items = append(result.Items, models.ConfigMapReference{
+				Kind:      kind,
+				Name:      object.GetName(),
+				NameSpace: object.GetNamespace(),
+				Usages:    usages,
+			})
+		}
+	}
+
+	pods, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	for _, pod := range pods {
+		add("Pod", pod, &pod.Spec)
+	}
+	deployments, err := c.Cache.Factory.Apps().V1().Deployments().Lister().Deployments(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	for _, deployment := range deployments {
+		add("Deployment", deployment, &deployment.Spec.Template.Spec)
+	}
+	statefulSets, err := c.Cache.Factory.Apps().V1().StatefulSets().Lister().StatefulSets(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	for _, statefulSet := range statefulSets {
+		add("StatefulSet", statefulSet, &statefulSet.Spec.Template.Spec)
+	}
+	daemonSets, err := c.Cache.Factory.Apps().V1().DaemonSets().Lister().DaemonSets(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	for _, daemonSet := range daemonSets {
+		add("DaemonSet", daemonSet, &daemonSet.Spec.Template.Spec)
+	}
+	cronJobs, err := c.Cache.Factory.Batch().V1().CronJobs().Lister().CronJobs(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CONFIGMAPS)
+	}
+	for _, cronJob := range cronJobs {
+		add("CronJob", cronJob, &cronJob.Spec.JobTemplate.Spec.Template.Spec)
+	}
+
+	sort.Slice(result.Items, func(i, j int) bool {
+		if result.Items[i].Kind != result.Items[j].Kind {
+			return result.Items[i].Kind < result.Items[j].Kind
+		}
+		return result.Items[i].Name < result.Items[j].Name
+	})
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CONFIGMAPS,
+	})
+}
+
+func (c ConfigMapsController) toList(configMaps []*v1.ConfigMap) *v1.ConfigMapList {
+	list := &v1.ConfigMapList{
+		Items: make([]v1.ConfigMap, 0, len(configMaps)),
+	}
+	for _, item := range configMaps {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
+
+func configMapFromForm(form *multipart.Form) (*v1.ConfigMap, error) {
+	name := ""
+	if values := form.Value["name"]; len(values) > 0 {
+		name = values[0]
+	}
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	configMap := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Data:       map[string]string{},
+		BinaryData: map[string][]byte{},
+	}
+	for _, files := range form.File {
+		for _, file := range files {
+			key := filepath.Base(file.Filename)
+			if errs := validation.IsConfigMapKey(key); len(errs) > 0 {
+				return nil, fmt.Errorf("invalid key %q: %s", key, strings.Join(errs, ", "))
+			}
+			if _, ok := configMap.Data[key]; ok {
+				return nil, fmt.Errorf("duplicate key %q", key)
+			}
+			if _, ok := configMap.BinaryData[key]; ok {
+				return nil, fmt.Errorf("duplicate key %q", key)
+			}
+			content, err := readFormFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if utf8.Valid(content) {
+				configMap.Data[key] = string(content)
+			} else {
+				configMap.BinaryData[key] = content
+			}
+		}
+	}
+	if len(configMap.Data)+len(configMap.BinaryData) == 0 {
+		return nil, errors.New("no file was uploaded")
+	}
+	return configMap, nil
+}
+
+func readFormFile(file *multipart.FileHeader) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// configMapUsages tells how a pod spec uses the config map.
+func configMapUsages(spec *v1.PodSpec, name string) []string {
+	usages := []string{}
+	for _, volume := range spec.Volumes {
+		if volume.ConfigMap != nil && volume.ConfigMap.Name == name {
+			usages = append(usages, "volume "+volume.Name)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.ConfigMap != nil && source.ConfigMap.Name == name {
+					usages = append(usages, "volume "+volume.Name)
+				}
+			}
+		}
+	}
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range spec.EphemeralContainers {
+		containers = append(containers, v1.Container(container.EphemeralContainerCommon))
+	}
+	for _, container := range containers {
+		for _, source := range container.EnvFrom {
+			if source.ConfigMapRef != nil && source.ConfigMapRef.Name == name {
+				usages = append(usages, "envFrom "+container.Name)
+			}
+		}
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == name {
+				usages = append(usages, fmt.Sprintf("env %s/%s", container.Name, env.Name))
+			}
+		}
+	}
+	return usages
+}
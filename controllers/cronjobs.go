@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/batch/v1"
+	core1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// defaultJobRuns is how many runs of a cron job are listed when the limit
+// query parameter is not given.
+const defaultJobRuns = 5
+
+type CronJobsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c CronJobsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Batch().V1().CronJobs().Informer(), utils.RESOUCETYPE_CRONJOBS, buffer)
+}
+
+func (c CronJobsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Cache.Factory.Batch().V1().CronJobs().Lister().CronJobs(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CRONJOBS,
+	})
+}
+
+func (c CronJobsController) Get(context echo.Context, nameSpaceName string) error {
+	query, err := parseListQuery(context, &v1.CronJob{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	cronJobs, err := c.Cache.Factory.Batch().V1().CronJobs().Lister().CronJobs(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	result := c.toList(cronJobs)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CRONJOBS,
+	})
+}
+
+func (c CronJobsController) Create(context echo.Context, nameSpaceName string, cronJobConfig map[string]interface{}) error {
+	cronJob := &v1.CronJob{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(cronJobConfig), cronJob)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	result, err := client.Clientset.BatchV1().CronJobs(nameSpaceName).Create(ctx.TODO(), cronJob, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CRONJOBS,
+	})
+}
+
+// Delete removes the cron job along with the jobs it started.
+func (c CronJobsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	propagation := metav1.DeletePropagationBackground
+	err = client.Clientset.BatchV1().CronJobs(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: utils.RESOUCETYPE_CRONJOBS,
+	})
+}
+
+// Trigger runs the cron job now, the way kubectl create job --from does:
+// the job is created from the job template and owned by the cron job, so it
+// shows in its runs and is deleted with it.
+func (c CronJobsController) Trigger(context echo.Context, nameSpaceName string, name string) error {
+	cronJob, err := c.Cache.Factory.Batch().V1().CronJobs().Lister().CronJobs(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	annotations := map[string]string{
+		"cronjob.kubernetes.io/instantiate": "manual",
+	}
+	for key, value := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[key] = value
+	}
+	job := &v1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-manual-", cronJob.Name),
+			Namespace:    nameSpaceName,
+			Labels:       cronJob.Spec.JobTemplate.Labels,
+			Annotations:  annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(cronJob, v1.SchemeGroupVersion.WithKind("CronJob")),
+			},
+		},
+		Spec: *cronJob.Spec.JobTemplate.Spec.DeepCopy(),
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+	result, err := client.Clientset.BatchV1().Jobs(nameSpaceName).Create(ctx.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_JOBS,
+	})
+}
+
+// Suspend stops the cron job from scheduling new runs, or lets it schedule
+// them again. Running jobs are not affected.
+func (c CronJobsController) Suspend(context echo.Context, nameSpaceName string, name string, suspend bool) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"suspend": suspend,
+		},
+	})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	result, err := client.Clientset.BatchV1().CronJobs(nameSpaceName).Patch(ctx.TODO(), name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CRONJOBS,
+	})
+}
+
+// Runs returns the latest jobs started by the cron job, newest first, with
+// the pods each of them ran. The limit query parameter sets how many, 0
+// for all of them.
+func (c CronJobsController) Runs(context echo.Context, nameSpaceName string, name string) error {
+	limit, err := int64QueryParam(context, "limit")
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	count := defaultJobRuns
+	if limit != nil {
+		count = int(*limit)
+	}
+
+	cronJob, err := c.Cache.Factory.Batch().V1().CronJobs().Lister().CronJobs(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	jobs, err := c.Cache.Factory.Batch().V1().Jobs().Lister().Jobs(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+	}
+	runs := make([]*v1.Job, 0)
+	for _, job := range jobs {
+		if metav1.IsControlledBy(job, cronJob) {
+			runs = append(runs, job)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[j].CreationTimestamp.Before(&runs[i].CreationTimestamp)
+	})
+	if count > 0 && len(runs) > count {
+		runs = runs[:count]
+	}
+
+	result := &models.JobRunList{
+		Items: make([]models.JobRun, 0, len(runs)),
+	}
+	for _, job := range runs {
+		pods, err := c.jobPods(job)
+		if err != nil {
+			return errorResponse(context, err, utils.RESOUCETYPE_CRONJOBS)
+		}
+		result.Items = append(result.Items, models.JobRun{
+			Job:  job,
+			Pods: pods,
+		})
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_CRONJOBS,
+	})
+}
+
+func (c CronJobsController) jobPods(job *v1.Job) ([]core1.Pod, error) {
+	pods := make([]core1.Pod, 0)
+	if job.Spec.Selector == nil {
+		return pods, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	items, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(job.Namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range items {
+		if metav1.IsControlledBy(pod, job) {
+			pods = append(pods, *pod)
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return objectKey(&pods[i]) < objectKey(&pods[j])
+	})
+	return pods, nil
+}
+
+func (c CronJobsController) toList(cronJobs []*v1.CronJob) *v1.CronJobList {
+	list := &v1.CronJobList{
+		Items: make([]v1.CronJob, 0, len(cronJobs)),
+	}
+	for _, item := range cronJobs {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type DaemonSetsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c DaemonSetsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Apps().V1().DaemonSets().Informer(), utils.RESOUCETYPE_DAEMONSETS, buffer)
+}
+func (c DaemonSetsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Cache.Factory.Apps().V1().DaemonSets().Lister().DaemonSets(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_DAEMONSETS,
+	})
+}
+
+func (c DaemonSetsController) Get(context echo.Context, nameSpaceName string) error {
+	query, err := parseListQuery(context, &v1.DaemonSet{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	daemonSets, err := c.Cache.Factory.Apps().V1().DaemonSets().Lister().DaemonSets(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+	result := c.toList(daemonSets)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_DAEMONSETS,
+	})
+}
+
+func (c DaemonSetsController) Create(context echo.Context, nameSpaceName string, daemonSetConfig map[string]interface{}) error {
+	daemonSet := &v1.DaemonSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(daemonSetConfig), daemonSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+	result, err := client.Clientset.AppsV1().DaemonSets(nameSpaceName).Create(ctx.TODO(), daemonSet, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_DAEMONSETS,
+	})
+}
+
+func (c DaemonSetsController) Update(context echo.Context, nameSpaceName string, daemonSetConfig map[string]interface{}) error {
+	daemonSet := &v1.DaemonSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(daemonSetConfig), daemonSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+	result, err := client.Clientset.AppsV1().DaemonSets(nameSpaceName).Update(ctx.TODO(), daemonSet, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_DAEMONSETS,
+	})
+}
+
+func (c DaemonSetsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+	err = client.Clientset.AppsV1().DaemonSets(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: utils.RESOUCETYPE_DAEMONSETS,
+	})
+}
+
+func (c DaemonSetsController) Restart(context echo.Context, nameSpaceName string, daemonSetConfig map[string]interface{}) error {
+	daemonSet := &v1.DaemonSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(daemonSetConfig), daemonSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	if daemonSet.Spec.Template.ObjectMeta.Annotations == nil {
+		daemonSet.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
+	}
+	daemonSet.Spec.Template.ObjectMeta.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+	result, err := client.Clientset.AppsV1().DaemonSets(nameSpaceName).Update(ctx.TODO(), daemonSet, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DAEMONSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_DAEMONSETS,
+	})
+}
+
+func (c DaemonSetsController) toList(daemonSets []*v1.DaemonSet) *v1.DaemonSetList {
+	list := &v1.DaemonSetList{
+		Items: make([]v1.DaemonSet, 0, len(daemonSets)),
+	}
+	for _, item := range daemonSets {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
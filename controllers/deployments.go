@@ -3,9 +3,8 @@ package controllers
 import (
 	ctx "context"
 	"encoding/json"
-	"log"
 	"net/http"
-	"strings"
+	"sort"
 	"time"
 
 	"github.com/kube-carbonara/cluster-agent/models"
@@ -14,12 +13,13 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/apps/v1"
-	CoreV1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 )
 
-type DeploymentsController struct{}
+type DeploymentsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (c DeploymentsController) WatchTest(session *utils.Session) {
 	go func() {
@@ -35,81 +35,13 @@ func (c DeploymentsController) WatchTest(session *utils.Session) {
 
 }
 
-func (c DeploymentsController) runWatcherEventLoop() error {
-	config := utils.NewConfig()
-	var client utils.Client = *utils.NewClient()
-	watch, err := client.Clientset.AppsV1().Deployments(CoreV1.NamespaceAll).Watch(ctx.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Fatal(err.Error())
-		return err
-	}
-
-	channel := watch.ResultChan()
-
-	done := make(chan struct{})
-
-	session := utils.Session{
-		Host:    config.RemoteProxy,
-		Channel: "monitoring",
-	}
-	session.NewSession()
-	defer session.Conn.Close()
-	defer close(done)
-	for {
-		select {
-		case event, ok := <-channel:
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-			obj, ok := event.Object.(*v1.Deployment)
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-
-			err := services.MonitoringService{
-				NameSpace: obj.Namespace,
-				EventName: string(event.Type),
-				Resource:  utils.RESOUCETYPE_DEPLOYMENTS,
-				PayLoad:   obj,
-			}.PushEvent(&session)
-
-			if err != nil {
-				logrus.Error(err)
-				session.Conn.Close()
-				session = *session.NewSession()
-				services.MonitoringService{
-					EventName: string(event.Type),
-					Resource:  utils.RESOUCETYPE_DEPLOYMENTS,
-					PayLoad:   obj,
-				}.PushEvent(&session)
-			}
-
-		case <-time.After(30 * time.Minute):
-			logrus.Info("Timeout, restarting event watcher")
-			return nil
-
-		}
-	}
-
-}
-
-func (c DeploymentsController) Watch() error {
-	for {
-		if err := c.runWatcherEventLoop(); err != nil {
-			logrus.Error(err)
-		}
-
-	}
+func (c DeploymentsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Apps().V1().Deployments().Informer(), utils.RESOUCETYPE_DEPLOYMENTS, buffer)
 }
 func (c DeploymentsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.AppsV1().Deployments(nameSpaceName).Get(ctx.TODO(), name, metav1.GetOptions{})
+	result, err := c.Cache.Factory.Apps().V1().Deployments().Lister().Deployments(nameSpaceName).Get(name)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -119,30 +51,19 @@ func (c DeploymentsController) GetOne(context echo.Context, nameSpaceName string
 }
 
 func (c DeploymentsController) Get(context echo.Context, nameSpaceName string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.AppsV1().Deployments(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{})
+	query, err := parseListQuery(context, &v1.Deployment{})
 	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
 			Message: err.Error(),
 		})
 	}
-
-	return context.JSON(http.StatusOK, models.Response{
-		Data:         utils.StructToMap(result),
-		ResourceType: utils.RESOUCETYPE_DEPLOYMENTS,
-	})
-}
-
-func (c DeploymentsController) GetBySelector(context echo.Context, nameSpaceName string, selector string) error {
-	labelSelector := c.parseSelector(selector)
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.AppsV1().Deployments(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{
-		LabelSelector: labels.SelectorFromSet(labelSelector).String(),
-	})
+	deployments, err := c.Cache.Factory.Apps().V1().Deployments().Lister().Deployments(nameSpaceName).List(query.labelSelector)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
+	}
+	result := c.toList(deployments)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -159,12 +80,13 @@ func (c DeploymentsController) Create(context echo.Context, nameSpaceName string
 			Message: UnmarshalErr.Error(),
 		})
 	}
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
+	}
 	result, err := client.Clientset.AppsV1().Deployments(nameSpaceName).Create(ctx.TODO(), deployment, metav1.CreateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -182,12 +104,13 @@ func (c DeploymentsController) Update(context echo.Context, nameSpaceName string
 		})
 	}
 
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
+	}
 	result, err := client.Clientset.AppsV1().Deployments(nameSpaceName).Update(ctx.TODO(), deployment, metav1.UpdateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -197,12 +120,13 @@ func (c DeploymentsController) Update(context echo.Context, nameSpaceName string
 }
 
 func (c DeploymentsController) Delete(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	err := client.Clientset.AppsV1().Deployments(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
+	}
+	err = client.Clientset.AppsV1().Deployments(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 
 	return context.JSON(http.StatusNoContent, models.Response{
@@ -226,12 +150,13 @@ func (c DeploymentsController) Restart(context echo.Context, nameSpaceName strin
 	}
 	deployment.Spec.Template.ObjectMeta.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
 
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
+	}
 	result, err := client.Clientset.AppsV1().Deployments(nameSpaceName).Update(ctx.TODO(), deployment, metav1.UpdateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -248,12 +173,15 @@ func (c DeploymentsController) ReScale(context echo.Context, nameSpaceName strin
 			Message: UnmarshalErr.Error(),
 		})
 	}
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
+	}
 	s, err := client.Clientset.AppsV1().
 		Deployments(nameSpaceName).
 		GetScale(ctx.TODO(), deployment.ObjectMeta.Name, metav1.GetOptions{})
 	if err != nil {
-		log.Fatal(err)
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 
 	sc := *s
@@ -265,9 +193,7 @@ func (c DeploymentsController) ReScale(context echo.Context, nameSpaceName strin
 			deployment.ObjectMeta.Name, &sc, metav1.UpdateOptions{})
 
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_DEPLOYMENTS)
 	}
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(result),
@@ -275,18 +201,15 @@ func (c DeploymentsController) ReScale(context echo.Context, nameSpaceName strin
 	})
 }
 
-func (c DeploymentsController) parseSelector(selector string) labels.Set {
-	specSelector := map[string]string{}
-	selectors := strings.Split(selector, ";")
-	for _, v := range selectors {
-		if s := strings.Split(v, "="); len(s) > 1 {
-			key := s[0]
-			value := s[1]
-			specSelector[key] = value
-		}
-
+func (c DeploymentsController) toList(deployments []*v1.Deployment) *v1.DeploymentList {
+	list := &v1.DeploymentList{
+		Items: make([]v1.Deployment, 0, len(deployments)),
 	}
-	set := labels.Set(specSelector)
-	return set
-
+	for _, item := range deployments {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
 }
@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+)
+
+// DynamicController serves any resource the API server knows, CRDs
+// included, through the dynamic client. Resources are resolved with
+// discovery, so routes of namespaced resources need a namespace and routes
+// of cluster-scoped resources must not have one. Unlike the typed
+// controllers reads go to the API server, as the impersonated user.
+type DynamicController struct {
+	Client *utils.Client
+}
+
+// DynamicRequest is the resource addressed by a route, with the namespace
+// of the route when it has one. An empty namespace of a namespaced route
+// stands for all namespaces.
+type DynamicRequest struct {
+	Resource   schema.GroupVersionResource
+	NameSpace  string
+	Namespaced bool
+}
+
+// secretsResource is served by SecretsController only, which redacts the
+// values the dynamic client would return in clear.
+var secretsResource = schema.GroupResource{Resource: "secrets"}
+
+type dynamicWatchEvent struct {
+	Type   watch.EventType `json:"type"`
+	Object interface{}     `json:"object"`
+}
+
+// Watch streams the changes of a resource, given as group/version/resource,
+// on the monitoring channel. A resource which can not be resolved yet, like
+// a CRD installed after the agent, is looked up again with backoff until
+// the context is done.
+func (c DynamicController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer, resource string) {
+	gvr, err := utils.ParseGroupVersionResource(resource)
+	if err != nil {
+		logrus.Error("not monitoring ", resource, ": ", err)
+		return
+	}
+	backoff := utils.NewBackoff()
+	for {
+		mapping, err := c.Client.RESTMapping(gvr)
+		if err == nil && mapping.Resource.GroupResource() == secretsResource {
+			logrus.Error("not monitoring ", resource, ": secrets are monitored by their own watcher")
+			return
+		}
+		if err == nil {
+			factory := dynamicinformer.NewDynamicSharedInformerFactory(c.Client.Dynamic, 0)
+			informer := factory.ForResource(mapping.Resource).Informer()
+			factory.Start(watchCtx.Done())
+			runInformerEventLoop(watchCtx, informer, mapping.Resource.GroupResource().String(), buffer)
+			return
+		}
+
+		wait := backoff.Step()
+		logrus.WithError(err).Warnf("unable to resolve %s to monitor it, retrying in %s", resource, wait)
+		select {
+		case <-watchCtx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Get lists the resource. With watch=true the changes are streamed
+// instead, over a websocket when the request asks for an upgrade and as
+// newline separated JSON events otherwise.
+func (c DynamicController) Get(context echo.Context, request DynamicRequest) error {
+	resource, resourceType, err := c.resource(context, request, false)
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+	watching, err := boolQueryParam(context, "watch")
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	limit, err := int64QueryParam(context, "limit")
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	options := metav1.ListOptions{
+		LabelSelector:   context.QueryParam("labelSelector"),
+		FieldSelector:   context.QueryParam("fieldSelector"),
+		Continue:        context.QueryParam("continue"),
+		ResourceVersion: context.QueryParam("resourceVersion"),
+	}
+	if limit != nil {
+		options.Limit = *limit
+	}
+	if watching {
+		return c.watch(context, resource, options, resourceType)
+	}
+
+	result, err := resource.List(context.Request().Context(), options)
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: resourceType,
+	})
+}
+
+func (c DynamicController) GetOne(context echo.Context, request DynamicRequest, name string) error {
+	resource, resourceType, err := c.resource(context, request, true)
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+	result, err := resource.Get(context.Request().Context(), name, metav1.GetOptions{})
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: resourceType,
+	})
+}
+
+func (c DynamicController) Create(context echo.Context, request DynamicRequest, objectConfig map[string]interface{}) error {
+	resource, resourceType, err := c.resource(context, request, true)
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+	if len(objectConfig) == 0 {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: "the request body must be a JSON object",
+		})
+	}
+	result, err := resource.Create(context.Request().Context(), &unstructured.Unstructured{Object: objectConfig}, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: resourceType,
+	})
+}
+
+func (c DynamicController) Update(context echo.Context, request DynamicRequest, objectConfig map[string]interface{}) error {
+	resource, resourceType, err := c.resource(context, request, true)
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+	object := &unstructured.Unstructured{Object: objectConfig}
+	if object.GetName() == "" {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: "metadata.name is required",
+		})
+	}
+	result, err := resource.Update(context.Request().Context(), object, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: resourceType,
+	})
+}
+
+// Delete removes the object along with the objects it owns.
+func (c DynamicController) Delete(context echo.Context, request DynamicRequest, name string) error {
+	resource, resourceType, err := c.resource(context, request, true)
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+	propagation := metav1.DeletePropagationBackground
+	err = resource.Delete(context.Request().Context(), name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: resourceType,
+	})
+}
+
+// resource resolves the resource of the request for the user. Single
+// objects of a namespaced resource are only reached through a namespace.
+func (c DynamicController) resource(context echo.Context, request DynamicRequest, single bool) (dynamic.ResourceInterface, string, error) {
+	resourceType := request.Resource.GroupResource().String()
+	mapping, err := c.Client.RESTMapping(request.Resource)
+	if meta.IsNoMatchError(err) {
+		notFound := apierrors.NewNotFound(request.Resource.GroupResource(), "")
+		notFound.ErrStatus.Message = fmt.Sprintf("the server doesn't have a resource type %q in %s", request.Resource.Resource, request.Resource.GroupVersion())
+		return nil, resourceType, notFound
+	}
+	if err != nil {
+		return nil, resourceType, err
+	}
+	resourceType = mapping.Resource.GroupResource().String()
+	if mapping.Resource.GroupResource() == secretsResource {
+		return nil, resourceType, apierrors.NewForbidden(secretsResource, "", errors.New("secrets are only served by /:ns/secrets"))
+	}
+
+	namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+	if request.Namespaced && !namespaced {
+		return nil, resourceType, apierrors.NewBadRequest(fmt.Sprintf("%s is cluster-scoped and has no namespace", resourceType))
+	}
+	if namespaced && single && request.NameSpace == "" {
+		return nil, resourceType, apierrors.NewBadRequest(fmt.Sprintf("%s is namespaced, a namespace is required", resourceType))
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return nil, resourceType, err
+	}
+	if namespaced {
+		return client.Dynamic.Resource(mapping.Resource).Namespace(request.NameSpace), resourceType, nil
+	}
+	return client.Dynamic.Resource(mapping.Resource), resourceType, nil
+}
+
+func (c DynamicController) watch(context echo.Context, resource dynamic.ResourceInterface, options metav1.ListOptions, resourceType string) error {
+	streamCtx, cancel := streamContext(context)
+	defer cancel()
+	watcher, err := resource.Watch(streamCtx, options)
+	if err != nil {
+		return errorResponse(context, err, resourceType)
+	}
+	defer watcher.Stop()
+
+	if websocket.IsWebSocketUpgrade(context.Request()) {
+		return c.watchWebsocket(context, watcher, cancel)
+	}
+	return c.watchChunked(context, watcher)
+}
+
+func (c DynamicController) watchChunked(context echo.Context, watcher watch.Interface) error {
+	response := context.Response()
+	response.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	encoder := json.NewEncoder(response)
+	for event := range watcher.ResultChan() {
+		if err := encoder.Encode(dynamicWatchEvent{Type: event.Type, Object: event.Object}); err != nil {
+			return nil
+		}
+		response.Flush()
+	}
+	return nil
+}
+
+func (c DynamicController) watchWebsocket(context echo.Context, watcher watch.Interface, cancel ctx.CancelFunc) error {
+	conn, err := upgrader.Upgrade(context.Response(), context.Request(), nil)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	// the client only ever closes the socket, which ends the watch
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for event := range watcher.ResultChan() {
+		conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+		if err := conn.WriteJSON(dynamicWatchEvent{Type: event.Type, Object: event.Object}); err != nil {
+			return nil
+		}
+	}
+	conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	return nil
+}
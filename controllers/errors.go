@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var reasonStatusCodes = map[metav1.StatusReason]int{
+	metav1.StatusReasonBadRequest:            http.StatusBadRequest,
+	metav1.StatusReasonUnauthorized:          http.StatusUnauthorized,
+	metav1.StatusReasonForbidden:             http.StatusForbidden,
+	metav1.StatusReasonNotFound:              http.StatusNotFound,
+	metav1.StatusReasonAlreadyExists:         http.StatusConflict,
+	metav1.StatusReasonConflict:              http.StatusConflict,
+	metav1.StatusReasonGone:                  http.StatusGone,
+	metav1.StatusReasonInvalid:               http.StatusUnprocessableEntity,
+	metav1.StatusReasonMethodNotAllowed:      http.StatusMethodNotAllowed,
+	metav1.StatusReasonNotAcceptable:         http.StatusNotAcceptable,
+	metav1.StatusReasonRequestEntityTooLarge: http.StatusRequestEntityTooLarge,
+	metav1.StatusReasonUnsupportedMediaType:  http.StatusUnsupportedMediaType,
+	metav1.StatusReasonExpired:               http.StatusGone,
+	metav1.StatusReasonTooManyRequests:       http.StatusTooManyRequests,
+	metav1.StatusReasonServiceUnavailable:    http.StatusServiceUnavailable,
+	metav1.StatusReasonTimeout:               http.StatusGatewayTimeout,
+	metav1.StatusReasonServerTimeout:         http.StatusGatewayTimeout,
+	metav1.StatusReasonInternalError:         http.StatusInternalServerError,
+}
+
+// errorResponse translates an error returned by client-go into the matching
+// HTTP status code and a structured body carrying the kubernetes reason,
+// details and causes. Errors which are not API statuses are internal errors.
+func errorResponse(context echo.Context, err error, resourceType string) error {
+	response := models.ErrorResponse{
+		Response: models.Response{
+			ResourceType: resourceType,
+			Message:      err.Error(),
+		},
+		Reason: string(metav1.StatusReasonUnknown),
+	}
+
+	var apiStatus apierrors.APIStatus
+	if !errors.As(err, &apiStatus) {
+		return context.JSON(http.StatusInternalServerError, response)
+	}
+	status := apiStatus.Status()
+	response.Reason = string(status.Reason)
+	if status.Details != nil {
+		response.Details = &models.ErrorDetails{
+			Name:  status.Details.Name,
+			Group: status.Details.Group,
+			Kind:  status.Details.Kind,
+		}
+		for _, cause := range status.Details.Causes {
+			response.Causes = append(response.Causes, models.ErrorCause{
+				Type:    string(cause.Type),
+				Message: cause.Message,
+				Field:   cause.Field,
+			})
+		}
+	}
+
+	code, ok := reasonStatusCodes[status.Reason]
+	if !ok {
+		code = int(status.Code)
+	}
+	if code < http.StatusBadRequest {
+		code = http.StatusInternalServerError
+	}
+	return context.JSON(code, response)
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	ctx "context"
+	"net/http"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/middlewares"
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+const apiServerCheckTimeout = 5 * time.Second
+
+// HealthController reports the state of the agent for liveness and
+// readiness probes. Dependencies left nil are not checked, so the same
+// controller serves the REST API and the tunnel-only status server.
+// With RequireIdentity the checks are only shown to authenticated callers,
+// the others get the overall status alone.
+type HealthController struct {
+	Client          *utils.Client
+	Cache           *utils.InformerCache
+	Session         *utils.Session
+	Buffer          *services.EventBuffer
+	Tunnel          *services.Tunnel
+	RequireIdentity bool
+}
+
+// Live answers as long as the process serves requests. It never checks
+// external dependencies, so their outages do not get the agent restarted.
+func (c HealthController) Live(context echo.Context) error {
+	return context.JSON(http.StatusOK, models.Response{
+		Data: utils.StructToMap(models.HealthStatus{
+			Status: models.HEALTH_OK,
+			Checks: map[string]models.HealthCheck{},
+		}),
+		ResourceType: utils.RESOUCETYPE_HEALTH,
+	})
+}
+
+// Ready reports every dependency and fails with 503 when a critical one is
+// down: the API server and the informers, or the tunnel when it is all the
+// agent runs.
+func (c HealthController) Ready(context echo.Context) error {
+	checks := map[string]models.HealthCheck{}
+	if c.Client != nil {
+		checks["apiServer"] = c.checkAPIServer(context.Request().Context())
+	}
+	if c.Cache != nil {
+		unsynced := c.Cache.Unsynced()
+		check := models.HealthCheck{Healthy: len(unsynced) == 0, Critical: true}
+		if !check.Healthy {
+			check.Message = "informer caches are not synced"
+			check.Details = map[string]interface{}{"unsynced": unsynced}
+		}
+		checks["informers"] = check
+	}
+	if c.Session != nil {
+		check := models.HealthCheck{Healthy: c.Session.Connected()}
+		if !check.Healthy {
+			check.Message = "monitoring websocket is disconnected, events are buffered"
+		}
+		if c.Buffer != nil {
+			check.Details = utils.StructToMap(c.Buffer.Stats())
+		}
+		checks["monitoring"] = check
+	}
+	if c.Tunnel != nil {
+		status := c.Tunnel.Status()
+		checks["tunnel"] = models.HealthCheck{
+			Healthy:  status.Connected,
+			Critical: c.Client == nil,
+			Message:  status.LastError,
+			Details:  utils.StructToMap(status),
+		}
+	}
+
+	result := models.HealthStatus{
+		Status: models.HEALTH_OK,
+		Checks: checks,
+	}
+	code := http.StatusOK
+	for _, check := range checks {
+		if check.Critical && !check.Healthy {
+			result.Status = models.HEALTH_FAILING
+			code = http.StatusServiceUnavailable
+		}
+	}
+	if c.RequireIdentity && middlewares.CurrentIdentity(context) == nil {
+		result.Checks = map[string]models.HealthCheck{}
+	}
+	return context.JSON(code, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_HEALTH,
+	})
+}
+
+func (c HealthController) checkAPIServer(requestCtx ctx.Context) models.HealthCheck {
+	checkCtx, cancel := ctx.WithTimeout(requestCtx, apiServerCheckTimeout)
+	defer cancel()
+	started := time.Now()
+	_, err := c.Client.Clientset.Discovery().RESTClient().Get().AbsPath("/version").DoRaw(checkCtx)
+	check := models.HealthCheck{
+		Healthy:  err == nil,
+		Critical: true,
+		Details: map[string]interface{}{
+			"latency": time.Since(started).String(),
+		},
+	}
+	if err != nil {
+		check.Message = err.Error()
+	}
+	return check
+}
@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kube-carbonara/cluster-agent/middlewares"
+	"github.com/kube-carbonara/cluster-agent/models"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+func TestReadyChecks(t *testing.T) {
+	tests := []struct {
+		name            string
+		requireIdentity bool
+		identity        *models.Identity
+		wantChecks      bool
+	}{
+		{name: "unauthenticated", requireIdentity: true, wantChecks: false},
+		{name: "authenticated", requireIdentity: true, identity: &models.Identity{Subject: "jane"}, wantChecks: true},
+		{name: "status server", requireIdentity: false, wantChecks: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			context := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), recorder)
+			if tt.identity != nil {
+				context.Set(middlewares.IdentityContextKey, tt.identity)
+			}
+			controller := HealthController{Session: &utils.Session{}, RequireIdentity: tt.requireIdentity}
+			if err := controller.Ready(context); err != nil {
+				t.Fatal(err)
+			}
+
+			var response struct {
+				Data models.HealthStatus `json:"data"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Data.Status != models.HEALTH_OK {
+				t.Errorf("status = %q, want %q", response.Data.Status, models.HEALTH_OK)
+			}
+			if _, ok := response.Data.Checks["monitoring"]; ok != tt.wantChecks {
+				t.Errorf("checks = %v, want checks %v", response.Data.Checks, tt.wantChecks)
+			}
+		})
+	}
+}
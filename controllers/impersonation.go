@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/kube-carbonara/cluster-agent/middlewares"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// userClient returns the client mutating calls run with. When the proxy
+// forwarded the end user, the call impersonates them so that cluster RBAC
+// and audit logs apply to the real person; otherwise the agent's own
+// service account is used, unless impersonation is required.
+func userClient(context echo.Context, client *utils.Client) (*utils.Client, error) {
+	identity := middlewares.CurrentIdentity(context)
+	if identity == nil || identity.User == "" {
+		if utils.NewConfig().RequireImpersonation {
+			return nil, apierrors.NewForbidden(schema.GroupResource{}, "", errors.New("no user to impersonate was forwarded by the proxy"))
+		}
+		return client, nil
+	}
+	return client.Impersonate(identity.User, identity.Groups)
+}
@@ -3,21 +3,21 @@ package controllers
 import (
 	ctx "context"
 	"encoding/json"
-	"log"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/kube-carbonara/cluster-agent/models"
 	services "github.com/kube-carbonara/cluster-agent/services"
 	utils "github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
-	CoreV1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type IngressController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (c IngressController) WatchTest(session *utils.Session) {
@@ -32,82 +32,14 @@ func (c IngressController) WatchTest(session *utils.Session) {
 
 }
 
-func (c IngressController) runWatcherEventLoop() error {
-	config := utils.NewConfig()
-	var client utils.Client = *utils.NewClient()
-	watch, err := client.Networkingv1client.Ingresses(CoreV1.NamespaceAll).Watch(ctx.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Fatal(err.Error())
-		return err
-	}
-
-	channel := watch.ResultChan()
-
-	done := make(chan struct{})
-
-	session := utils.Session{
-		Host:    config.RemoteProxy,
-		Channel: "monitoring",
-	}
-	session.NewSession()
-	defer session.Conn.Close()
-	defer close(done)
-	for {
-		select {
-		case event, ok := <-channel:
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-			obj, ok := event.Object.(*networkingv1.Ingress)
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-
-			err := services.MonitoringService{
-				NameSpace: obj.Namespace,
-				EventName: string(event.Type),
-				Resource:  utils.RESOUCETYPE_INGRESS,
-				PayLoad:   obj,
-			}.PushEvent(&session)
-
-			if err != nil {
-				logrus.Error(err)
-				session.Conn.Close()
-				session = *session.NewSession()
-				services.MonitoringService{
-					EventName: string(event.Type),
-					Resource:  utils.RESOUCETYPE_INGRESS,
-					PayLoad:   obj,
-				}.PushEvent(&session)
-			}
-
-		case <-time.After(30 * time.Minute):
-			logrus.Info("Timeout, restarting event watcher")
-			return nil
-
-		}
-	}
-
-}
-
-func (c IngressController) Watch() {
-	for {
-		if err := c.runWatcherEventLoop(); err != nil {
-			logrus.Error(err)
-		}
-
-	}
+func (c IngressController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Networking().V1().Ingresses().Informer(), utils.RESOUCETYPE_INGRESS, buffer)
 }
 
 func (c IngressController) GetOne(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Networkingv1client.Ingresses(nameSpaceName).Get(ctx.TODO(), name, metav1.GetOptions{})
+	result, err := c.Cache.Factory.Networking().V1().Ingresses().Lister().Ingresses(nameSpaceName).Get(name)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -117,13 +49,20 @@ func (c IngressController) GetOne(context echo.Context, nameSpaceName string, na
 }
 
 func (c IngressController) Get(context echo.Context, nameSpaceName string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Networkingv1client.Ingresses(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{})
+	query, err := parseListQuery(context, &networkingv1.Ingress{})
 	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
 			Message: err.Error(),
 		})
 	}
+	ingresses, err := c.Cache.Factory.Networking().V1().Ingresses().Lister().Ingresses(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
+	}
+	result := c.toList(ingresses)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
+	}
 
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(result),
@@ -140,12 +79,13 @@ func (c IngressController) Create(context echo.Context, nameSpaceName string, in
 		})
 	}
 
-	var client utils.Client = *utils.NewClient()
-	ingress, err := client.Networkingv1client.Ingresses(nameSpaceName).Create(ctx.TODO(), ingress, metav1.CreateOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
+	}
+	ingress, err = client.Networkingv1client.Ingresses(nameSpaceName).Create(ctx.TODO(), ingress, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
 	}
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(ingress),
@@ -161,12 +101,13 @@ func (c IngressController) Update(context echo.Context, nameSpaceName string, in
 			Message: UnmarshalErr.Error(),
 		})
 	}
-	var client utils.Client = *utils.NewClient()
-	ingress, err := client.Networkingv1client.Ingresses(nameSpaceName).Update(ctx.TODO(), ingress, metav1.UpdateOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
+	}
+	ingress, err = client.Networkingv1client.Ingresses(nameSpaceName).Update(ctx.TODO(), ingress, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
 	}
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(ingress),
@@ -175,12 +116,26 @@ func (c IngressController) Update(context echo.Context, nameSpaceName string, in
 }
 
 func (c IngressController) Delete(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	err := client.Networkingv1client.Ingresses(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
+	}
+	err = client.Networkingv1client.Ingresses(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_INGRESS)
 	}
 	return context.JSON(http.StatusNoContent, nil)
 }
+
+func (c IngressController) toList(ingresses []*networkingv1.Ingress) *networkingv1.IngressList {
+	list := &networkingv1.IngressList{
+		Items: make([]networkingv1.Ingress, 0, len(ingresses)),
+	}
+	for _, item := range ingresses {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
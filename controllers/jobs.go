@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type JobsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c JobsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Batch().V1().Jobs().Informer(), utils.RESOUCETYPE_JOBS, buffer)
+}
+
+func (c JobsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Cache.Factory.Batch().V1().Jobs().Lister().Jobs(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_JOBS,
+	})
+}
+
+func (c JobsController) Get(context echo.Context, nameSpaceName string) error {
+	query, err := parseListQuery(context, &v1.Job{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	jobs, err := c.Cache.Factory.Batch().V1().Jobs().Lister().Jobs(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+	result := c.toList(jobs)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_JOBS,
+	})
+}
+
+func (c JobsController) Create(context echo.Context, nameSpaceName string, jobConfig map[string]interface{}) error {
+	job := &v1.Job{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(jobConfig), job)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+	result, err := client.Clientset.BatchV1().Jobs(nameSpaceName).Create(ctx.TODO(), job, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_JOBS,
+	})
+}
+
+// Delete removes the job along with its pods, which the API server would
+// otherwise orphan.
+func (c JobsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+	propagation := metav1.DeletePropagationBackground
+	err = client.Clientset.BatchV1().Jobs(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{
+		PropagationPolicy: &propagation,
+	})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_JOBS)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: utils.RESOUCETYPE_JOBS,
+	})
+}
+
+func (c JobsController) toList(jobs []*v1.Job) *v1.JobList {
+	list := &v1.JobList{
+		Items: make([]v1.Job, 0, len(jobs)),
+	}
+	for _, item := range jobs {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
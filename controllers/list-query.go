@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// listQuery holds the selectors and paging requested on a list route. The
+// labelSelector (or the legacy selector=a=b;c=d) takes the full kubernetes
+// syntax, next to fieldSelector, limit and the continue token of a page.
+// Only the fields the listed kind can be selected on are accepted.
+type listQuery struct {
+	labelSelector labels.Selector
+	fieldSelector fields.Selector
+	limit         int
+	continueKey   string
+}
+
+func parseListQuery(context echo.Context, kind runtime.Object) (listQuery, error) {
+	query := listQuery{
+		labelSelector: labels.Everything(),
+		fieldSelector: fields.Everything(),
+	}
+
+	selector := context.QueryParam("labelSelector")
+	if selector == "" {
+		selector = strings.ReplaceAll(context.QueryParam("selector"), ";", ",")
+	}
+	if selector != "" {
+		labelSelector, err := labels.Parse(selector)
+		if err != nil {
+			return query, err
+		}
+		query.labelSelector = labelSelector
+	}
+
+	if fieldSelector := context.QueryParam("fieldSelector"); fieldSelector != "" {
+		parsed, err := fields.ParseSelector(fieldSelector)
+		if err != nil {
+			return query, err
+		}
+		for _, requirement := range parsed.Requirements() {
+			if !selectableField(kind, requirement.Field) {
+				return query, fmt.Errorf("field label not supported: %s", requirement.Field)
+			}
+		}
+		query.fieldSelector = parsed
+	}
+
+	if limit := context.QueryParam("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed < 0 {
+			return query, errors.New("limit must be a non-negative integer")
+		}
+		query.limit = parsed
+	}
+
+	if token := context.QueryParam("continue"); token != "" {
+		key, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return query, errors.New("invalid continue token")
+		}
+		query.continueKey = string(key)
+	}
+	return query, nil
+}
+
+// paginate filters a sorted list on the field selector and cuts the page
+// starting after the continue token. The continue token of the next page
+// and the count of remaining items are set on the list metadata.
+func (q listQuery) paginate(list runtime.Object) error {
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+
+	page := make([]runtime.Object, 0, len(items))
+	remaining := int64(0)
+	lastKey := ""
+	for _, item := range items {
+		obj, err := meta.Accessor(item)
+		if err != nil {
+			return err
+		}
+		key := objectKey(obj)
+		if q.continueKey != "" && key <= q.continueKey {
+			continue
+		}
+		if !q.fieldSelector.Empty() && !q.fieldSelector.Matches(objectFields(item, obj)) {
+			continue
+		}
+		if q.limit > 0 && len(page) >= q.limit {
+			remaining++
+			continue
+		}
+		page = append(page, item)
+		lastKey = key
+	}
+
+	listMeta, err := meta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		listMeta.SetContinue(base64.RawURLEncoding.EncodeToString([]byte(lastKey)))
+		listMeta.SetRemainingItemCount(&remaining)
+	}
+	return meta.SetList(list, page)
+}
+
+// objectFields returns the fields an object can be selected on, mirroring
+// the field selectors supported by the API server.
+func objectFields(item runtime.Object, obj metav1.Object) fields.Set {
+	set := fields.Set{
+		"metadata.name":      obj.GetName(),
+		"metadata.namespace": obj.GetNamespace(),
+	}
+	switch o := item.(type) {
+	case *v1.Pod:
+		set["spec.nodeName"] = o.Spec.NodeName
+		set["spec.restartPolicy"] = string(o.Spec.RestartPolicy)
+		set["spec.schedulerName"] = o.Spec.SchedulerName
+		set["spec.serviceAccountName"] = o.Spec.ServiceAccountName
+		set["status.phase"] = string(o.Status.Phase)
+		set["status.podIP"] = o.Status.PodIP
+		set["status.nominatedNodeName"] = o.Status.NominatedNodeName
+	case *v1.Event:
+		set["involvedObject.kind"] = o.InvolvedObject.Kind
+		set["involvedObject.namespace"] = o.InvolvedObject.Namespace
+		set["involvedObject.name"] = o.InvolvedObject.Name
+		set["involvedObject.uid"] = string(o.InvolvedObject.UID)
+		set["involvedObject.apiVersion"] = o.InvolvedObject.APIVersion
+		set["involvedObject.resourceVersion"] = o.InvolvedObject.ResourceVersion
+		set["involvedObject.fieldPath"] = o.InvolvedObject.FieldPath
+		set["reason"] = o.Reason
+		set["reportingComponent"] = o.ReportingController
+		set["source"] = o.Source.Component
+		set["type"] = o.Type
+	case *v1.Secret:
+		set["type"] = string(o.Type)
+	case *v1.Namespace:
+		set["status.phase"] = string(o.Status.Phase)
+	case *v1.Node:
+		set["spec.unschedulable"] = strconv.FormatBool(o.Spec.Unschedulable)
+	case *appsv1.ReplicaSet:
+		set["status.replicas"] = strconv.Itoa(int(o.Status.Replicas))
+	}
+	return set
+}
+
+// selectableField tells whether objects of a kind can be selected on a
+// field.
+func selectableField(kind runtime.Object, field string) bool {
+	obj, err := meta.Accessor(kind)
+	if err != nil {
+		return false
+	}
+	_, ok := objectFields(kind, obj)[field]
+	return ok
+}
+
+// objectKey orders cached objects the same way the API server lists them.
+func objectKey(obj metav1.Object) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func listContext(target string) echo.Context {
+	return echo.New().NewContext(httptest.NewRequest(http.MethodGet, target, nil), httptest.NewRecorder())
+}
+
+func TestParseListQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		kind    runtime.Object
+		wantErr string
+	}{
+		{name: "empty", target: "/default/pods", kind: &v1.Pod{}},
+		{name: "label selector", target: "/default/pods?labelSelector=env+in+(prod,staging),!canary", kind: &v1.Pod{}},
+		{name: "legacy selector", target: "/default/pods?selector=app=web;tier=front", kind: &v1.Pod{}},
+		{name: "invalid label selector", target: "/default/pods?labelSelector=a%3D%3D%3Db", kind: &v1.Pod{}, wantErr: "unable to parse requirement: found '=', expected: identifier"},
+		{name: "metadata field", target: "/default/configmaps?fieldSelector=metadata.name%3Dsettings", kind: &v1.ConfigMap{}},
+		{name: "pod field", target: "/default/pods?fieldSelector=status.phase%3DRunning,spec.nodeName!%3Dnode-1", kind: &v1.Pod{}},
+		{name: "event field", target: "/default/events?fieldSelector=involvedObject.name%3Dweb", kind: &v1.Event{}},
+		{name: "unsupported field", target: "/default/pods?fieldSelector=spec.hostname%3Dweb", kind: &v1.Pod{}, wantErr: "field label not supported: spec.hostname"},
+		{name: "field of another kind", target: "/default/configmaps?fieldSelector=status.phase%3DRunning", kind: &v1.ConfigMap{}, wantErr: "field label not supported: status.phase"},
+		{name: "limit", target: "/default/pods?limit=50", kind: &v1.Pod{}},
+		{name: "limit zero", target: "/default/pods?limit=0", kind: &v1.Pod{}},
+		{name: "negative limit", target: "/default/pods?limit=-1", kind: &v1.Pod{}, wantErr: "limit must be a non-negative integer"},
+		{name: "limit not a number", target: "/default/pods?limit=ten", kind: &v1.Pod{}, wantErr: "limit must be a non-negative integer"},
+		{name: "invalid continue", target: "/default/pods?continue=%25", kind: &v1.Pod{}, wantErr: "invalid continue token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseListQuery(listContext(tt.target), tt.kind)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("parseListQuery() error = %v", err)
+			case tt.wantErr != "" && err == nil:
+				t.Fatalf("parseListQuery() expected an error")
+			case tt.wantErr != "" && err.Error() != tt.wantErr:
+				t.Fatalf("parseListQuery() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func testPods(names ...string) *v1.PodList {
+	list := &v1.PodList{}
+	for i, name := range names {
+		phase := v1.PodRunning
+		if i%2 == 1 {
+			phase = v1.PodPending
+		}
+		list.Items = append(list.Items, v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     v1.PodStatus{Phase: phase},
+		})
+	}
+	return list
+}
+
+func podNames(list *v1.PodList) []string {
+	names := []string{}
+	for _, pod := range list.Items {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestPaginate(t *testing.T) {
+	names := []string{"a", "b", "c", "d", "e"}
+	tests := []struct {
+		name      string
+		query     string
+		wantPages [][]string
+	}{
+		{name: "no limit", query: "", wantPages: [][]string{{"a", "b", "c", "d", "e"}}},
+		{name: "limit zero", query: "limit=0", wantPages: [][]string{{"a", "b", "c", "d", "e"}}},
+		{name: "pages of two", query: "limit=2", wantPages: [][]string{{"a", "b"}, {"c", "d"}, {"e"}}},
+		{name: "exact pages", query: "limit=5", wantPages: [][]string{{"a", "b", "c", "d", "e"}}},
+		{name: "limit over size", query: "limit=10", wantPages: [][]string{{"a", "b", "c", "d", "e"}}},
+		{name: "field selector", query: "fieldSelector=status.phase%3DRunning&limit=2", wantPages: [][]string{{"a", "c"}, {"e"}}},
+		{name: "name selector", query: "fieldSelector=metadata.name%3Dd", wantPages: [][]string{{"d"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pages [][]string
+			token := ""
+			for len(pages) <= len(names) {
+				target := "/default/pods?" + tt.query
+				if token != "" {
+					target += "&continue=" + token
+				}
+				query, err := parseListQuery(listContext(target), &v1.Pod{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				list := testPods(names...)
+				if err := query.paginate(list); err != nil {
+					t.Fatal(err)
+				}
+				pages = append(pages, podNames(list))
+
+				token = list.Continue
+				if token == "" {
+					if list.RemainingItemCount != nil {
+						t.Errorf("last page has remainingItemCount %d", *list.RemainingItemCount)
+					}
+					break
+				}
+				if list.RemainingItemCount == nil || *list.RemainingItemCount <= 0 {
+					t.Errorf("page %d has a continue token but no remainingItemCount", len(pages))
+				}
+			}
+			if fmt.Sprint(pages) != fmt.Sprint(tt.wantPages) {
+				t.Errorf("pages = %v, want %v", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestPaginateRemainingItemCount(t *testing.T) {
+	query, err := parseListQuery(listContext("/default/pods?limit=2&fieldSelector=status.phase%3DRunning"), &v1.Pod{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := testPods("a", "b", "c", "d", "e", "f", "g")
+	if err := query.paginate(list); err != nil {
+		t.Fatal(err)
+	}
+	// a, c, e, g are running: two on this page, two left
+	if list.RemainingItemCount == nil || *list.RemainingItemCount != 2 {
+		t.Errorf("remainingItemCount = %v, want 2", list.RemainingItemCount)
+	}
+}
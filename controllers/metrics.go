@@ -10,47 +10,51 @@ import (
 	"github.com/labstack/echo/v4"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
-type MetricsController struct{}
+type MetricsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (c MetricsController) NodeMetrics(context echo.Context) error {
-	var client utils.Client = *utils.NewClient()
-	metrics, err := client.MetricsV1beta1.NodeMetricses().List(ctx.TODO(), metav1.ListOptions{})
+	metrics, err := c.Client.MetricsV1beta1.NodeMetricses().List(ctx.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
-	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx.TODO(), metav1.ListOptions{})
+	nodes, err := c.listNodes()
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
-	nodeRowMetrics := RowNodeMetrics(metrics.Items, nodes.Items)
+	nodeRowMetrics := RowNodeMetrics(metrics.Items, nodes)
 	return context.JSON(http.StatusOK, nodeRowMetrics)
 }
 
 func (c MetricsController) ClusterMetrics(context echo.Context) error {
-	var client utils.Client = *utils.NewClient()
-	metrics, err := client.MetricsV1beta1.NodeMetricses().List(ctx.TODO(), metav1.ListOptions{})
+	metrics, err := c.Client.MetricsV1beta1.NodeMetricses().List(ctx.TODO(), metav1.ListOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
-	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx.TODO(), metav1.ListOptions{})
+	nodes, err := c.listNodes()
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
-	ClusterRowMetrics := RowClusterMetrics(metrics.Items, nodes.Items)
+	ClusterRowMetrics := RowClusterMetrics(metrics.Items, nodes)
 	return context.JSON(http.StatusOK, ClusterRowMetrics)
 }
 
+// listNodes returns the cached nodes sorted by name, matching the order of
+// the node metrics list.
+func (c MetricsController) listNodes() ([]v1.Node, error) {
+	nodes, err := c.Cache.Factory.Core().V1().Nodes().Lister().List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	return NodesController{}.toList(nodes).Items, nil
+}
+
 func RowNodeMetrics(metrics []v1beta1.NodeMetrics, nodes []v1.Node) (rows []models.NodeRowMetrics) {
 	for k, v := range nodes {
 		var row models.NodeRowMetrics
@@ -2,20 +2,21 @@ package controllers
 
 import (
 	ctx "context"
-	"log"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/kube-carbonara/cluster-agent/models"
 	services "github.com/kube-carbonara/cluster-agent/services"
 	utils "github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type NameSpacesController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (c NameSpacesController) WatchTest(session *utils.Session) {
@@ -30,82 +31,14 @@ func (c NameSpacesController) WatchTest(session *utils.Session) {
 
 }
 
-func (c NameSpacesController) runWatcherEventLoop() error {
-	config := utils.NewConfig()
-	var client utils.Client = *utils.NewClient()
-	watch, err := client.Clientset.CoreV1().Namespaces().Watch(ctx.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Fatal(err.Error())
-		return err
-	}
-
-	channel := watch.ResultChan()
-
-	done := make(chan struct{})
-
-	session := utils.Session{
-		Host:    config.RemoteProxy,
-		Channel: "monitoring",
-	}
-	session.NewSession()
-	defer session.Conn.Close()
-	defer close(done)
-	for {
-		select {
-		case event, ok := <-channel:
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-			obj, ok := event.Object.(*v1.Namespace)
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-
-			err := services.MonitoringService{
-				NameSpace: obj.Namespace,
-				EventName: string(event.Type),
-				Resource:  utils.RESOUCETYPE_NAMESPACES,
-				PayLoad:   obj,
-			}.PushEvent(&session)
-
-			if err != nil {
-				logrus.Error(err)
-				session.Conn.Close()
-				session = *session.NewSession()
-				services.MonitoringService{
-					EventName: string(event.Type),
-					Resource:  utils.RESOUCETYPE_NAMESPACES,
-					PayLoad:   obj,
-				}.PushEvent(&session)
-			}
-
-		case <-time.After(30 * time.Minute):
-			logrus.Info("Timeout, restarting event watcher")
-			return nil
-
-		}
-	}
-
-}
-
-func (c NameSpacesController) Watch() {
-	for {
-		if err := c.runWatcherEventLoop(); err != nil {
-			logrus.Error(err)
-		}
-
-	}
+func (c NameSpacesController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Core().V1().Namespaces().Informer(), utils.RESOUCETYPE_NAMESPACES, buffer)
 }
 
 func (c NameSpacesController) GetOne(context echo.Context, name string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Namespaces().Get(ctx.TODO(), name, metav1.GetOptions{})
+	result, err := c.Cache.Factory.Core().V1().Namespaces().Lister().Get(name)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NAMESPACES)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -115,13 +48,20 @@ func (c NameSpacesController) GetOne(context echo.Context, name string) error {
 }
 
 func (c NameSpacesController) Get(context echo.Context) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Namespaces().List(ctx.TODO(), metav1.ListOptions{})
+	query, err := parseListQuery(context, &v1.Namespace{})
 	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
 			Message: err.Error(),
 		})
 	}
+	namespaces, err := c.Cache.Factory.Core().V1().Namespaces().Lister().List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NAMESPACES)
+	}
+	result := c.toList(namespaces)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NAMESPACES)
+	}
 
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(result),
@@ -130,12 +70,13 @@ func (c NameSpacesController) Get(context echo.Context) error {
 }
 
 func (c NameSpacesController) Delete(context echo.Context, name string) error {
-	var client utils.Client = *utils.NewClient()
-	err := client.Clientset.CoreV1().Namespaces().Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NAMESPACES)
+	}
+	err = client.Clientset.CoreV1().Namespaces().Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NAMESPACES)
 	}
 	return context.JSON(http.StatusNoContent, nil)
 }
@@ -146,15 +87,29 @@ func (c NameSpacesController) Create(context echo.Context, name string) error {
 			Name: name,
 		},
 	}
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NAMESPACES)
+	}
 	result, err := client.Clientset.CoreV1().Namespaces().Create(ctx.TODO(), ns, metav1.CreateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NAMESPACES)
 	}
 	return context.JSON(http.StatusCreated, models.Response{
 		ResourceType: utils.RESOUCETYPE_NAMESPACES,
 		Data:         utils.StructToMap(result),
 	})
 }
+
+func (c NameSpacesController) toList(namespaces []*v1.Namespace) *v1.NamespaceList {
+	list := &v1.NamespaceList{
+		Items: make([]v1.Namespace, 0, len(namespaces)),
+	}
+	for _, item := range namespaces {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
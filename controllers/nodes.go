@@ -3,97 +3,30 @@ package controllers
 import (
 	ctx "context"
 	"encoding/json"
-	"log"
 	"net/http"
-	"time"
+	"sort"
 
 	"github.com/kube-carbonara/cluster-agent/models"
 	services "github.com/kube-carbonara/cluster-agent/services"
 	utils "github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-type NodesController struct{}
-
-func (c NodesController) runWatcherEventLoop() error {
-	config := utils.NewConfig()
-	var client utils.Client = *utils.NewClient()
-	watch, err := client.Clientset.CoreV1().Nodes().Watch(ctx.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Fatal(err.Error())
-		return err
-	}
-
-	channel := watch.ResultChan()
-
-	done := make(chan struct{})
-
-	session := utils.Session{
-		Host:    config.RemoteProxy,
-		Channel: "monitoring",
-	}
-	session.NewSession()
-	defer session.Conn.Close()
-	defer close(done)
-	for {
-		select {
-		case event, ok := <-channel:
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-			obj, ok := event.Object.(*v1.Node)
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-
-			err := services.MonitoringService{
-				NameSpace: obj.Namespace,
-				EventName: string(event.Type),
-				Resource:  utils.RESOUCETYPE_NODES,
-				PayLoad:   obj,
-			}.PushEvent(&session)
-
-			if err != nil {
-				logrus.Error(err)
-				session.Conn.Close()
-				session = *session.NewSession()
-				services.MonitoringService{
-					EventName: string(event.Type),
-					Resource:  utils.RESOUCETYPE_NODES,
-					PayLoad:   obj,
-				}.PushEvent(&session)
-			}
-
-		case <-time.After(30 * time.Minute):
-			logrus.Info("Timeout, restarting event watcher")
-			return nil
-
-		}
-	}
-
+type NodesController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
-func (c NodesController) Watch() {
-	for {
-		if err := c.runWatcherEventLoop(); err != nil {
-			logrus.Error(err)
-		}
-
-	}
+func (c NodesController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Core().V1().Nodes().Informer(), utils.RESOUCETYPE_NODES, buffer)
 }
 
 func (c NodesController) GetOne(context echo.Context, name string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Nodes().Get(ctx.TODO(), name, metav1.GetOptions{})
+	result, err := c.Cache.Factory.Core().V1().Nodes().Lister().Get(name)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -103,13 +36,20 @@ func (c NodesController) GetOne(context echo.Context, name string) error {
 }
 
 func (c NodesController) Get(context echo.Context) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Nodes().List(ctx.TODO(), metav1.ListOptions{})
+	query, err := parseListQuery(context, &v1.Node{})
 	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
 			Message: err.Error(),
 		})
 	}
+	nodes, err := c.Cache.Factory.Core().V1().Nodes().Lister().List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
+	}
+	result := c.toList(nodes)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
+	}
 
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(result),
@@ -118,12 +58,13 @@ func (c NodesController) Get(context echo.Context) error {
 }
 
 func (c NodesController) Delete(context echo.Context, name string) error {
-	var client utils.Client = *utils.NewClient()
-	err := client.Clientset.CoreV1().Nodes().Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
+	}
+	err = client.Clientset.CoreV1().Nodes().Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
 	return context.JSON(http.StatusNoContent, nil)
 }
@@ -136,12 +77,13 @@ func (c NodesController) Create(context echo.Context, nodeConfig map[string]inte
 			Message: UnmarshalErr.Error(),
 		})
 	}
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
+	}
 	result, err := client.Clientset.CoreV1().Nodes().Create(ctx.TODO(), node, metav1.CreateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
 	return context.JSON(http.StatusCreated, models.Response{
 		ResourceType: utils.RESOUCETYPE_NODES,
@@ -157,12 +99,13 @@ func (c NodesController) Update(context echo.Context, nodeConfig map[string]inte
 			Message: UnmarshalErr.Error(),
 		})
 	}
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
+	}
 	result, err := client.Clientset.CoreV1().Nodes().Update(ctx.TODO(), node, metav1.UpdateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_NODES)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -170,3 +113,16 @@ func (c NodesController) Update(context echo.Context, nodeConfig map[string]inte
 		ResourceType: utils.RESOUCETYPE_NODES,
 	})
 }
+
+func (c NodesController) toList(nodes []*v1.Node) *v1.NodeList {
+	list := &v1.NodeList{
+		Items: make([]v1.Node, 0, len(nodes)),
+	}
+	for _, item := range nodes {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
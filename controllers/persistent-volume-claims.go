@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type PersistentVolumeClaimsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c PersistentVolumeClaimsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Core().V1().PersistentVolumeClaims().Informer(), utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS, buffer)
+}
+func (c PersistentVolumeClaimsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Cache.Factory.Core().V1().PersistentVolumeClaims().Lister().PersistentVolumeClaims(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS,
+	})
+}
+
+func (c PersistentVolumeClaimsController) Get(context echo.Context, nameSpaceName string) error {
+	query, err := parseListQuery(context, &v1.PersistentVolumeClaim{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	claims, err := c.Cache.Factory.Core().V1().PersistentVolumeClaims().Lister().PersistentVolumeClaims(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+	result := c.toList(claims)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS,
+	})
+}
+
+func (c PersistentVolumeClaimsController) Create(context echo.Context, nameSpaceName string, claimConfig map[string]interface{}) error {
+	claim := &v1.PersistentVolumeClaim{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(claimConfig), claim)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+	result, err := client.Clientset.CoreV1().PersistentVolumeClaims(nameSpaceName).Create(ctx.TODO(), claim, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS,
+	})
+}
+
+func (c PersistentVolumeClaimsController) Update(context echo.Context, nameSpaceName string, claimConfig map[string]interface{}) error {
+	claim := &v1.PersistentVolumeClaim{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(claimConfig), claim)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+	result, err := client.Clientset.CoreV1().PersistentVolumeClaims(nameSpaceName).Update(ctx.TODO(), claim, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS,
+	})
+}
+
+func (c PersistentVolumeClaimsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+	err = client.Clientset.CoreV1().PersistentVolumeClaims(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS,
+	})
+}
+
+func (c PersistentVolumeClaimsController) toList(claims []*v1.PersistentVolumeClaim) *v1.PersistentVolumeClaimList {
+	list := &v1.PersistentVolumeClaimList{
+		Items: make([]v1.PersistentVolumeClaim, 0, len(claims)),
+	}
+	for _, item := range claims {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
+
+// Binding joins the claim to its volume, storage class, consuming pods and
+// events, to tell why a claim or the pods using it are pending.
+func (c PersistentVolumeClaimsController) Binding(context echo.Context, nameSpaceName string, name string) error {
+	claim, err := c.Cache.Factory.Core().V1().PersistentVolumeClaims().Lister().PersistentVolumeClaims(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+	result := &models.VolumeBinding{
+		Claim:  claim,
+		Pods:   make([]v1.Pod, 0),
+		Events: make([]v1.Event, 0),
+	}
+
+	className := ""
+	if claim.Spec.StorageClassName != nil {
+		className = *claim.Spec.StorageClassName
+	}
+	if claim.Spec.VolumeName != "" {
+		result.Volume, err = c.Cache.Factory.Core().V1().PersistentVolumes().Lister().Get(claim.Spec.VolumeName)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+		}
+		if result.Volume != nil && className == "" {
+			className = result.Volume.Spec.StorageClassName
+		}
+	}
+	if className != "" {
+		result.StorageClass, err = c.Cache.Factory.Storage().V1().StorageClasses().Lister().Get(className)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+		}
+	}
+
+	pods, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+	for _, pod := range pods {
+		if podUsesClaim(pod, name) {
+			result.Pods = append(result.Pods, *pod)
+		}
+	}
+	sort.Slice(result.Pods, func(i, j int) bool {
+		return objectKey(&result.Pods[i]) < objectKey(&result.Pods[j])
+	})
+
+	events, err := c.Cache.Factory.Core().V1().Events().Lister().Events(nameSpaceName).List(labels.Everything())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS)
+	}
+	for _, event := range events {
+		if event.InvolvedObject.UID == claim.UID {
+			result.Events = append(result.Events, *event)
+		}
+	}
+	sort.Slice(result.Events, func(i, j int) bool {
+		return eventTime(&result.Events[i]).Before(eventTime(&result.Events[j]))
+	})
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMECLAIMS,
+	})
+}
+
+// eventTime is when an event last happened. Events of the events.k8s.io API
+// only set eventTime, and the creation time is the last resort.
+func eventTime(event *v1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	}
+	return event.CreationTimestamp.Time
+}
+
+// podUsesClaim tells whether the pod mounts the claim, directly or as the
+// claim created for one of its generic ephemeral volumes.
+func podUsesClaim(pod *v1.Pod, name string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim != nil && volume.PersistentVolumeClaim.ClaimName == name {
+			return true
+		}
+		if volume.Ephemeral != nil && pod.Name+"-"+volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEventTimeOrder(t *testing.T) {
+	base := time.Date(2021, 9, 1, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return base.Add(time.Duration(minutes) * time.Minute)
+	}
+	events := []v1.Event{
+		{
+			ObjectMeta:    metav1.ObjectMeta{Name: "last-timestamp", CreationTimestamp: metav1.NewTime(at(0))},
+			LastTimestamp: metav1.NewTime(at(4)),
+			EventTime:     metav1.NewMicroTime(at(1)),
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "event-time", CreationTimestamp: metav1.NewTime(at(5))},
+			EventTime:  metav1.NewMicroTime(at(3)),
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "created", CreationTimestamp: metav1.NewTime(at(2))},
+		},
+		{
+			ObjectMeta:    metav1.ObjectMeta{Name: "oldest", CreationTimestamp: metav1.NewTime(at(0))},
+			LastTimestamp: metav1.NewTime(at(1)),
+		},
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(&events[i]).Before(eventTime(&events[j]))
+	})
+
+	names := []string{}
+	for _, event := range events {
+		names = append(names, event.Name)
+	}
+	if want := "[oldest created event-time last-timestamp]"; fmt.Sprint(names) != want {
+		t.Errorf("order = %v, want %s", names, want)
+	}
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/core/v1"
+)
+
+type PersistentVolumesController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c PersistentVolumesController) GetOne(context echo.Context, name string) error {
+	result, err := c.Cache.Factory.Core().V1().PersistentVolumes().Lister().Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMES)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMES,
+	})
+}
+
+func (c PersistentVolumesController) Get(context echo.Context) error {
+	query, err := parseListQuery(context, &v1.PersistentVolume{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	volumes, err := c.Cache.Factory.Core().V1().PersistentVolumes().Lister().List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMES)
+	}
+	result := c.toList(volumes)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PERSISTENTVOLUMES)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PERSISTENTVOLUMES,
+	})
+}
+
+func (c PersistentVolumesController) toList(volumes []*v1.PersistentVolume) *v1.PersistentVolumeList {
+	list := &v1.PersistentVolumeList{
+		Items: make([]v1.PersistentVolume, 0, len(volumes)),
+	}
+	for _, item := range volumes {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
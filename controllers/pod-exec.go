@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kube-carbonara/cluster-agent/models"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// Exec sessions are bridged over the websocket with one byte prefixing
+// every binary message to tell the streams apart, like the channel.k8s.io
+// protocol of the API server.
+const (
+	execStdin  byte = 0
+	execStdout byte = 1
+	execStderr byte = 2
+	execError  byte = 3
+	execResize byte = 4
+)
+
+// Exec runs a command in a pod container and bridges its streams to the
+// websocket the request is upgraded to.
+func (c PodsController) Exec(context echo.Context, nameSpaceName string, name string) error {
+	options, err := podExecOptions(context)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	if len(options.Command) == 0 {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: "command is required",
+		})
+	}
+	return c.stream(context, nameSpaceName, name, "exec", options)
+}
+
+// Attach attaches to the main process of a pod container and bridges its
+// streams to the websocket the request is upgraded to.
+func (c PodsController) Attach(context echo.Context, nameSpaceName string, name string) error {
+	options, err := podExecOptions(context)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	return c.stream(context, nameSpaceName, name, "attach", options)
+}
+
+func (c PodsController) stream(context echo.Context, nameSpaceName string, name string, subresource string, options *v1.PodExecOptions) error {
+	if !websocket.IsWebSocketUpgrade(context.Request()) {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: subresource + " requires a websocket upgrade",
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
+
+	request := client.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(nameSpaceName).
+		Name(name).
+		SubResource(subresource)
+	if subresource == "attach" {
+		request.VersionedParams(&v1.PodAttachOptions{
+			Container: options.Container,
+			Stdin:     options.Stdin,
+			Stdout:    options.Stdout,
+			Stderr:    options.Stderr,
+			TTY:       options.TTY,
+		}, scheme.ParameterCodec)
+	} else {
+		request.VersionedParams(options, scheme.ParameterCodec)
+	}
+	transport, spdyUpgrader, err := spdy.RoundTripperFor(client.Config)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
+	streamUpgrader := &closableUpgrader{Upgrader: spdyUpgrader}
+	executor, err := remotecommand.NewSPDYExecutorForTransports(transport, streamUpgrader, http.MethodPost, request.URL())
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
+
+	conn, err := upgrader.Upgrade(context.Response(), context.Request(), nil)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	audit := auditEntry(context, subresource, logrus.Fields{
+		"namespace": nameSpaceName,
+		"pod":       name,
+		"container": options.Container,
+		"command":   options.Command,
+		"tty":       options.TTY,
+	})
+	audit.Info("session opened")
+	started := time.Now()
+
+	// the stream ends when the socket is closed or the agent shuts down,
+	// even when the command never reads its stdin
+	streamCtx, cancel := streamContext(context)
+	defer cancel()
+	session := newExecSession(conn, options.Stdin)
+	go func() {
+		defer cancel()
+		session.readLoop()
+	}()
+	go func() {
+		<-streamCtx.Done()
+		session.stdinW.Close()
+		streamUpgrader.Close()
+	}()
+	streamOptions := remotecommand.StreamOptions{
+		Stdout: session.writer(execStdout),
+		Tty:    options.TTY,
+	}
+	if options.Stdin {
+		streamOptions.Stdin = session.stdin
+	}
+	if options.Stderr {
+		streamOptions.Stderr = session.writer(execStderr)
+	}
+	if options.TTY {
+		streamOptions.TerminalSizeQueue = session
+	}
+	err = executor.Stream(streamOptions)
+
+	if err != nil {
+		session.write(execError, []byte(err.Error()))
+		audit.WithField("duration", time.Since(started).String()).WithError(err).Info("session closed")
+	} else {
+		audit.WithField("duration", time.Since(started).String()).Info("session closed")
+	}
+	session.close()
+	return nil
+}
+
+func podExecOptions(context echo.Context) (*v1.PodExecOptions, error) {
+	options := &v1.PodExecOptions{
+		Container: context.QueryParam("container"),
+		Command:   context.QueryParams()["command"],
+		Stdin:     true,
+		Stdout:    true,
+	}
+	var err error
+	if options.TTY, err = boolQueryParam(context, "tty"); err != nil {
+		return nil, err
+	}
+	if context.QueryParam("stdin") != "" {
+		if options.Stdin, err = boolQueryParam(context, "stdin"); err != nil {
+			return nil, err
+		}
+	}
+	// a terminal merges stderr into stdout
+	options.Stderr = !options.TTY
+	return options, nil
+}
+
+// execSession adapts a websocket to the streams of the remotecommand
+// executor: stdin and resize messages are read from the socket, stdout,
+// stderr and errors are written to it.
+type execSession struct {
+	conn         *websocket.Conn
+	stdinEnabled bool
+	stdin        *io.PipeReader
+	stdinW       *io.PipeWriter
+	sizes        chan remotecommand.TerminalSize
+	writeMu      sync.Mutex
+}
+
+func newExecSession(conn *websocket.Conn, stdinEnabled bool) *execSession {
+	stdin, stdinW := io.Pipe()
+	return &execSession{
+		conn:         conn,
+		stdinEnabled: stdinEnabled,
+		stdin:        stdin,
+		stdinW:       stdinW,
+		sizes:        make(chan remotecommand.TerminalSize, 1),
+	}
+}
+
+func (s *execSession) readLoop() {
+	defer close(s.sizes)
+	defer s.stdinW.Close()
+	for {
+		messageType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.BinaryMessage || len(data) == 0 {
+			continue
+		}
+		switch data[0] {
+		case execStdin:
+			if !s.stdinEnabled {
+				continue
+			}
+			if _, err := s.stdinW.Write(data[1:]); err != nil {
+				return
+			}
+		case execResize:
+			size := remotecommand.TerminalSize{}
+			if err := json.Unmarshal(data[1:], &size); err != nil {
+				continue
+			}
+			// only the latest size matters
+			select {
+			case <-s.sizes:
+			default:
+			}
+			s.sizes <- size
+		}
+	}
+}
+
+// Next implements remotecommand.TerminalSizeQueue.
+func (s *execSession) Next() *remotecommand.TerminalSize {
+	size, ok := <-s.sizes
+	if !ok {
+		return nil
+	}
+	return &size
+}
+
+func (s *execSession) write(channel byte, data []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	return s.conn.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, data...))
+}
+
+func (s *execSession) writer(channel byte) io.Writer {
+	return execWriter{session: s, channel: channel}
+}
+
+// close ends the session once the command exited: pending stdin writes
+// fail and the client is told the socket is closing.
+func (s *execSession) close() {
+	s.stdin.Close()
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+	s.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+type execWriter struct {
+	session *execSession
+	channel byte
+}
+
+func (w execWriter) Write(data []byte) (int, error) {
+	if err := w.session.write(w.channel, data); err != nil {
+		return 0, errors.New("websocket closed: " + err.Error())
+	}
+	return len(data), nil
+}
+
+// closableUpgrader keeps the SPDY connection opened by an executor, which
+// can not be cancelled otherwise, so that the stream can be ended from the
+// outside. A connection made after Close is closed right away.
+type closableUpgrader struct {
+	spdy.Upgrader
+	mu     sync.Mutex
+	conn   httpstream.Connection
+	closed bool
+}
+
+func (u *closableUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	conn, err := u.Upgrader.NewConnection(resp)
+	if err != nil {
+		return nil, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		conn.Close()
+		return nil, errors.New("stream closed")
+	}
+	u.conn = conn
+	return conn, nil
+}
+
+func (u *closableUpgrader) Close() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.closed = true
+	if u.conn != nil {
+		u.conn.Close()
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"net/http"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+type fakeConnection struct {
+	httpstream.Connection
+	closed int
+}
+
+func (c *fakeConnection) Close() error {
+	c.closed++
+	return nil
+}
+
+type fakeUpgrader struct {
+	conn *fakeConnection
+}
+
+func (u fakeUpgrader) NewConnection(resp *http.Response) (httpstream.Connection, error) {
+	return u.conn, nil
+}
+
+func TestClosableUpgrader(t *testing.T) {
+	tests := []struct {
+		name            string
+		closeFirst      bool
+		wantErr         bool
+		wantClosed      int
+		closeAfterwards bool
+	}{
+		{name: "closed while streaming", closeAfterwards: true, wantClosed: 1},
+		{name: "closed before connecting", closeFirst: true, wantErr: true, wantClosed: 1},
+		{name: "never closed", wantClosed: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConnection{}
+			upgrader := &closableUpgrader{Upgrader: fakeUpgrader{conn: conn}}
+			if tt.closeFirst {
+				upgrader.Close()
+			}
+			_, err := upgrader.NewConnection(&http.Response{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConnection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.closeAfterwards {
+				upgrader.Close()
+			}
+			if conn.closed != tt.wantClosed {
+				t.Errorf("connection closed %d times, want %d", conn.closed, tt.wantClosed)
+			}
+		})
+	}
+}
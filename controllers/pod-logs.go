@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"bufio"
+	ctx "context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kube-carbonara/cluster-agent/models"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Logs returns the logs of a pod container. With follow=true the lines are
+// streamed as they come, over a websocket when the request asks for an
+// upgrade and over a chunked plain text response otherwise. Logs are read
+// as the end user, so their RBAC on pods/log applies.
+func (c PodsController) Logs(context echo.Context, nameSpaceName string, name string) error {
+	options, err := podLogOptions(context)
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
+	request := client.Clientset.CoreV1().Pods(nameSpaceName).GetLogs(name, options)
+
+	if !options.Follow {
+		result, err := request.DoRaw(context.Request().Context())
+		if err != nil {
+			return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+		}
+		return context.JSON(http.StatusOK, models.Response{
+			Data: map[string]interface{}{
+				"namespace": nameSpaceName,
+				"name":      name,
+				"container": options.Container,
+				"logs":      string(result),
+			},
+			ResourceType: utils.RESOUCETYPE_PODS,
+		})
+	}
+
+	streamCtx, cancel := streamContext(context)
+	defer cancel()
+	stream, err := request.Stream(streamCtx)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
+	defer stream.Close()
+
+	if websocket.IsWebSocketUpgrade(context.Request()) {
+		return c.streamLogsWebsocket(context, stream, cancel)
+	}
+	return c.streamLogsChunked(context, stream)
+}
+
+func (c PodsController) streamLogsChunked(context echo.Context, stream io.Reader) error {
+	response := context.Response()
+	response.Header().Set(echo.HeaderContentType, echo.MIMETextPlainCharsetUTF8)
+	response.Header().Set(echo.HeaderXContentTypeOptions, "nosniff")
+	response.WriteHeader(http.StatusOK)
+	response.Flush()
+
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, writeErr := response.Write(line); writeErr != nil {
+				return nil
+			}
+			response.Flush()
+		}
+		if err != nil {
+			logStreamEnd(err)
+			return nil
+		}
+	}
+}
+
+func (c PodsController) streamLogsWebsocket(context echo.Context, stream io.Reader, cancel ctx.CancelFunc) error {
+	conn, err := upgrader.Upgrade(context.Response(), context.Request(), nil)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	// the client only ever closes the socket, which ends the stream
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+			if writeErr := conn.WriteMessage(websocket.TextMessage, line); writeErr != nil {
+				return nil
+			}
+		}
+		if err != nil {
+			logStreamEnd(err)
+			conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return nil
+		}
+	}
+}
+
+func logStreamEnd(err error) {
+	if !errors.Is(err, io.EOF) && !errors.Is(err, ctx.Canceled) {
+		logrus.Error("Error streaming pod logs: ", err.Error())
+	}
+}
+
+func podLogOptions(context echo.Context) (*v1.PodLogOptions, error) {
+	options := &v1.PodLogOptions{
+		Container: context.QueryParam("container"),
+	}
+	var err error
+	if options.Follow, err = boolQueryParam(context, "follow"); err != nil {
+		return nil, err
+	}
+	if options.Previous, err = boolQueryParam(context, "previous"); err != nil {
+		return nil, err
+	}
+	if options.Timestamps, err = boolQueryParam(context, "timestamps"); err != nil {
+		return nil, err
+	}
+	if options.TailLines, err = int64QueryParam(context, "tailLines"); err != nil {
+		return nil, err
+	}
+	if options.SinceSeconds, err = int64QueryParam(context, "sinceSeconds"); err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func boolQueryParam(context echo.Context, name string) (bool, error) {
+	value := context.QueryParam(name)
+	if value == "" {
+		return false, nil
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New(name + " must be a boolean")
+	}
+	return result, nil
+}
+
+func int64QueryParam(context echo.Context, name string) (*int64, error) {
+	value := context.QueryParam(name)
+	if value == "" {
+		return nil, nil
+	}
+	result, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || result < 0 {
+		return nil, errors.New(name + " must be a non-negative integer")
+	}
+	return &result, nil
+}
@@ -3,100 +3,30 @@ package controllers
 import (
 	ctx "context"
 	"encoding/json"
-	"log"
 	"net/http"
-	"strings"
-	"time"
+	"sort"
 
 	"github.com/kube-carbonara/cluster-agent/models"
 	services "github.com/kube-carbonara/cluster-agent/services"
 	utils "github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
-	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
 )
 
 type PodsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
-func (c PodsController) runWatcherEventLoop() error {
-	config := utils.NewConfig()
-	var client utils.Client = *utils.NewClient()
-	watch, err := client.Clientset.CoreV1().Pods(v1.NamespaceAll).Watch(ctx.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Fatal(err.Error())
-		return err
-	}
-
-	channel := watch.ResultChan()
-
-	done := make(chan struct{})
-
-	session := utils.Session{
-		Host:    config.RemoteProxy,
-		Channel: "monitoring",
-	}
-	session.NewSession()
-	defer session.Conn.Close()
-	defer close(done)
-	for {
-		select {
-		case event, ok := <-channel:
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-			obj, ok := event.Object.(*v1.Pod)
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-
-			err := services.MonitoringService{
-				NameSpace: obj.Namespace,
-				EventName: string(event.Type),
-				Resource:  utils.RESOUCETYPE_PODS,
-				PayLoad:   obj,
-			}.PushEvent(&session)
-
-			if err != nil {
-				logrus.Error(err)
-				session.Conn.Close()
-				session = *session.NewSession()
-				services.MonitoringService{
-					EventName: string(event.Type),
-					Resource:  utils.RESOUCETYPE_PODS,
-					PayLoad:   obj,
-				}.PushEvent(&session)
-			}
-
-		case <-time.After(30 * time.Minute):
-			logrus.Info("Timeout, restarting event watcher")
-			return nil
-
-		}
-	}
-
-}
-
-func (c PodsController) Watch() {
-	for {
-		if err := c.runWatcherEventLoop(); err != nil {
-			logrus.Error(err)
-		}
-
-	}
+func (c PodsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Core().V1().Pods().Informer(), utils.RESOUCETYPE_PODS, buffer)
 }
 
 func (c PodsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Pods(nameSpaceName).Get(ctx.TODO(), name, metav1.GetOptions{})
+	result, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(nameSpaceName).Get(name)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -106,30 +36,19 @@ func (c PodsController) GetOne(context echo.Context, nameSpaceName string, name
 }
 
 func (c PodsController) Get(context echo.Context, nameSpaceName string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Pods(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{})
+	query, err := parseListQuery(context, &v1.Pod{})
 	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
 			Message: err.Error(),
 		})
 	}
-
-	return context.JSON(http.StatusOK, models.Response{
-		Data:         utils.StructToMap(result),
-		ResourceType: utils.RESOUCETYPE_PODS,
-	})
-}
-
-func (c PodsController) GetBySelector(context echo.Context, nameSpaceName string, selector string) error {
-	labelSelector := c.parseSelector(selector)
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Pods(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{
-		LabelSelector: labels.SelectorFromSet(labelSelector).String(),
-	})
+	pods, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(nameSpaceName).List(query.labelSelector)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
+	result := c.toList(pods)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -146,12 +65,13 @@ func (c PodsController) Create(context echo.Context, nameSpaceName string, podCo
 			Message: UnmarshalErr.Error(),
 		})
 	}
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
 	result, err := client.Clientset.CoreV1().Pods(nameSpaceName).Create(ctx.TODO(), pod, metav1.CreateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -168,12 +88,13 @@ func (c PodsController) Update(context echo.Context, nameSpaceName string, podCo
 			Message: UnmarshalErr.Error(),
 		})
 	}
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
 	result, err := client.Clientset.CoreV1().Pods(nameSpaceName).Update(ctx.TODO(), pod, metav1.UpdateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -183,12 +104,13 @@ func (c PodsController) Update(context echo.Context, nameSpaceName string, podCo
 }
 
 func (c PodsController) Delete(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	err := client.Clientset.CoreV1().Pods(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
+	}
+	err = client.Clientset.CoreV1().Pods(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PODS)
 	}
 
 	return context.JSON(http.StatusNoContent, models.Response{
@@ -197,18 +119,15 @@ func (c PodsController) Delete(context echo.Context, nameSpaceName string, name
 	})
 }
 
-func (c PodsController) parseSelector(selector string) labels.Set {
-	specSelector := map[string]string{}
-	selectors := strings.Split(selector, ";")
-	for _, v := range selectors {
-		if s := strings.Split(v, "="); len(s) > 1 {
-			key := s[0]
-			value := s[1]
-			specSelector[key] = value
-		}
-
+func (c PodsController) toList(pods []*v1.Pod) *v1.PodList {
+	list := &v1.PodList{
+		Items: make([]v1.Pod, 0, len(pods)),
 	}
-	set := labels.Set(specSelector)
-	return set
-
+	for _, pod := range pods {
+		list.Items = append(list.Items, *pod)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
 }
@@ -0,0 +1,292 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kube-carbonara/cluster-agent/middlewares"
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const portForwardBufferSize = 32 * 1024
+
+type PortForwardsController struct {
+	Client   *utils.Client
+	Cache    *utils.InformerCache
+	Forwards *services.PortForwards
+}
+
+func (c PortForwardsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Forwards.Get(nameSpaceName, name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PORTFORWARDS)
+	}
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PORTFORWARDS,
+	})
+}
+
+func (c PortForwardsController) Get(context echo.Context, nameSpaceName string) error {
+	return context.JSON(http.StatusOK, models.Response{
+		Data: utils.StructToMap(&models.PortForwardList{
+			Items: c.Forwards.List(nameSpaceName),
+		}),
+		ResourceType: utils.RESOUCETYPE_PORTFORWARDS,
+	})
+}
+
+// Create opens a named tunnel to a pod port. The body names either the pod
+// or a service, which is resolved to a ready backing pod and the target
+// port of the requested service port.
+func (c PortForwardsController) Create(context echo.Context, nameSpaceName string, forwardConfig map[string]interface{}) error {
+	forward := models.PortForward{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(forwardConfig), &forward)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	forward.NameSpace = nameSpaceName
+	if forward.Name == "" || (forward.Pod == "") == (forward.Service == "") {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: "a name and either a pod or a service are required",
+		})
+	}
+
+	var err error
+	if forward.Service != "" {
+		forward, err = c.resolveService(forward)
+	} else {
+		forward, err = c.resolvePod(forward)
+	}
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PORTFORWARDS)
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PORTFORWARDS)
+	}
+	if identity := middlewares.CurrentIdentity(context); identity != nil {
+		forward.User = identity.User
+	}
+	result, err := c.Forwards.Start(client, forward)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PORTFORWARDS)
+	}
+	auditEntry(context, "portforward", logrus.Fields{
+		"namespace": result.NameSpace,
+		"name":      result.Name,
+		"pod":       result.Pod,
+		"service":   result.Service,
+		"port":      result.Port,
+	}).Info("tunnel opened")
+
+	return context.JSON(http.StatusCreated, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_PORTFORWARDS,
+	})
+}
+
+func (c PortForwardsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	err := c.Forwards.Stop(nameSpaceName, name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PORTFORWARDS)
+	}
+	auditEntry(context, "portforward", logrus.Fields{
+		"namespace": nameSpaceName,
+		"name":      name,
+	}).Info("tunnel closed")
+	return context.JSON(http.StatusNoContent, nil)
+}
+
+// Connect bridges the websocket the request is upgraded to with a new
+// connection through the tunnel. Binary messages carry the raw stream.
+func (c PortForwardsController) Connect(context echo.Context, nameSpaceName string, name string) error {
+	if !websocket.IsWebSocketUpgrade(context.Request()) {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: "connect requires a websocket upgrade",
+		})
+	}
+	forward, err := c.Forwards.Get(nameSpaceName, name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PORTFORWARDS)
+	}
+	if !canConnect(middlewares.CurrentIdentity(context), forward) {
+		return context.JSON(http.StatusForbidden, models.Response{
+			Message:      "the tunnel was opened by another user",
+			ResourceType: utils.RESOUCETYPE_PORTFORWARDS,
+		})
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(forward.LocalPort)))
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_PORTFORWARDS)
+	}
+	defer target.Close()
+
+	conn, err := upgrader.Upgrade(context.Response(), context.Request(), nil)
+	if err != nil {
+		return nil
+	}
+	defer conn.Close()
+
+	audit := auditEntry(context, "portforward", logrus.Fields{
+		"namespace": forward.NameSpace,
+		"name":      forward.Name,
+		"pod":       forward.Pod,
+		"port":      forward.Port,
+	})
+	audit.Info("connection opened")
+	started := time.Now()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buffer := make([]byte, portForwardBufferSize)
+		for {
+			n, err := target.Read(buffer)
+			if n > 0 {
+				conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+				if err := conn.WriteMessage(websocket.BinaryMessage, buffer[:n]); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout))
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				return
+			}
+		}
+	}()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+		if _, err := target.Write(data); err != nil {
+			break
+		}
+	}
+	target.Close()
+	<-done
+	audit.WithField("duration", time.Since(started).String()).Info("connection closed")
+	return nil
+}
+
+func (c PortForwardsController) resolvePod(forward models.PortForward) (models.PortForward, error) {
+	if forward.Port <= 0 {
+		return forward, apierrors.NewBadRequest("a port is required to forward to a pod")
+	}
+	_, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(forward.NameSpace).Get(forward.Pod)
+	return forward, err
+}
+
+func (c PortForwardsController) resolveService(forward models.PortForward) (models.PortForward, error) {
+	service, err := c.Cache.Factory.Core().V1().Services().Lister().Services(forward.NameSpace).Get(forward.Service)
+	if err != nil {
+		return forward, err
+	}
+	servicePort, err := findServicePort(service, forward.Port)
+	if err != nil {
+		return forward, err
+	}
+	if len(service.Spec.Selector) == 0 {
+		return forward, apierrors.NewBadRequest(fmt.Sprintf("service %s has no selector", service.Name))
+	}
+
+	pods, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(forward.NameSpace).List(labels.SelectorFromSet(service.Spec.Selector))
+	if err != nil {
+		return forward, err
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	for _, pod := range pods {
+		if !podReady(pod) {
+			continue
+		}
+		port, ok := targetPort(pod, servicePort)
+		if !ok {
+			continue
+		}
+		forward.Pod = pod.Name
+		forward.Port = port
+		return forward, nil
+	}
+	return forward, apierrors.NewServiceUnavailable(fmt.Sprintf("service %s has no ready pod", service.Name))
+}
+
+// findServicePort returns the service port matching the requested one, or
+// the only port of the service when none was requested.
+func findServicePort(service *v1.Service, port int) (v1.ServicePort, error) {
+	if port == 0 && len(service.Spec.Ports) == 1 {
+		return service.Spec.Ports[0], nil
+	}
+	for _, servicePort := range service.Spec.Ports {
+		if int(servicePort.Port) == port {
+			return servicePort, nil
+		}
+	}
+	return v1.ServicePort{}, apierrors.NewBadRequest(fmt.Sprintf("service %s has no port %d", service.Name, port))
+}
+
+func targetPort(pod *v1.Pod, servicePort v1.ServicePort) (int, bool) {
+	if servicePort.TargetPort.Type == intstr.String {
+		for _, container := range pod.Spec.Containers {
+			for _, port := range container.Ports {
+				if port.Name == servicePort.TargetPort.StrVal {
+					return int(port.ContainerPort), true
+				}
+			}
+		}
+		return 0, false
+	}
+	if servicePort.TargetPort.IntVal != 0 {
+		return int(servicePort.TargetPort.IntVal), true
+	}
+	return int(servicePort.Port), true
+}
+
+func podReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// canConnect tells whether the caller may use the tunnel. Tunnels are
+// opened with the credentials of their user, so only that user, or the
+// proxy acting for no user, may connect through them.
+func canConnect(identity *models.Identity, forward models.PortForward) bool {
+	if identity == nil {
+		return true
+	}
+	if identity.Subject == middlewares.ProxySubject && identity.User == "" {
+		return true
+	}
+	return identity.User == forward.User
+}
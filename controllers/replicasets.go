@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type ReplicaSetsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c ReplicaSetsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Apps().V1().ReplicaSets().Informer(), utils.RESOUCETYPE_REPLICASETS, buffer)
+}
+func (c ReplicaSetsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Cache.Factory.Apps().V1().ReplicaSets().Lister().ReplicaSets(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_REPLICASETS,
+	})
+}
+
+func (c ReplicaSetsController) Get(context echo.Context, nameSpaceName string) error {
+	query, err := parseListQuery(context, &v1.ReplicaSet{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	replicaSets, err := c.Cache.Factory.Apps().V1().ReplicaSets().Lister().ReplicaSets(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+	result := c.toList(replicaSets)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_REPLICASETS,
+	})
+}
+
+func (c ReplicaSetsController) Create(context echo.Context, nameSpaceName string, replicaSetConfig map[string]interface{}) error {
+	replicaSet := &v1.ReplicaSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(replicaSetConfig), replicaSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+	result, err := client.Clientset.AppsV1().ReplicaSets(nameSpaceName).Create(ctx.TODO(), replicaSet, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_REPLICASETS,
+	})
+}
+
+func (c ReplicaSetsController) Update(context echo.Context, nameSpaceName string, replicaSetConfig map[string]interface{}) error {
+	replicaSet := &v1.ReplicaSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(replicaSetConfig), replicaSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+	result, err := client.Clientset.AppsV1().ReplicaSets(nameSpaceName).Update(ctx.TODO(), replicaSet, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_REPLICASETS,
+	})
+}
+
+func (c ReplicaSetsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+	err = client.Clientset.AppsV1().ReplicaSets(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: utils.RESOUCETYPE_REPLICASETS,
+	})
+}
+
+func (c ReplicaSetsController) ReScale(context echo.Context, nameSpaceName string, scale int32, replicaSetConfig map[string]interface{}) error {
+	replicaSet := &v1.ReplicaSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(replicaSetConfig), replicaSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+	s, err := client.Clientset.AppsV1().
+		ReplicaSets(nameSpaceName).
+		GetScale(ctx.TODO(), replicaSet.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+
+	sc := *s
+	sc.Spec.Replicas = scale
+
+	result, err := client.Clientset.AppsV1().
+		ReplicaSets(nameSpaceName).
+		UpdateScale(ctx.TODO(),
+			replicaSet.ObjectMeta.Name, &sc, metav1.UpdateOptions{})
+
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_REPLICASETS)
+	}
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_REPLICASETS,
+	})
+}
+
+func (c ReplicaSetsController) toList(replicaSets []*v1.ReplicaSet) *v1.ReplicaSetList {
+	list := &v1.ReplicaSetList{
+		Items: make([]v1.ReplicaSet, 0, len(replicaSets)),
+	}
+	for _, item := range replicaSets {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
@@ -3,8 +3,8 @@ package controllers
 import (
 	ctx "context"
 	"encoding/json"
-	"log"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/kube-carbonara/cluster-agent/models"
@@ -17,6 +17,8 @@ import (
 )
 
 type ServicesController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (c ServicesController) WatchTest() {
@@ -37,82 +39,14 @@ func (c ServicesController) WatchTest() {
 	}
 
 }
-func (c ServicesController) runWatcherEventLoop() error {
-	config := utils.NewConfig()
-	var client utils.Client = *utils.NewClient()
-	watch, err := client.Clientset.CoreV1().Services(v1.NamespaceAll).Watch(ctx.TODO(), metav1.ListOptions{})
-	if err != nil {
-		log.Fatal(err.Error())
-		return err
-	}
-
-	channel := watch.ResultChan()
-
-	done := make(chan struct{})
-
-	session := utils.Session{
-		Host:    config.RemoteProxy,
-		Channel: "monitoring",
-	}
-	session.NewSession()
-	defer session.Conn.Close()
-	defer close(done)
-	for {
-		select {
-		case event, ok := <-channel:
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-			obj, ok := event.Object.(*v1.Service)
-			if !ok {
-				log.Fatal("unexpected type")
-				return nil
-			}
-
-			err := services.MonitoringService{
-				NameSpace: obj.Namespace,
-				EventName: string(event.Type),
-				Resource:  utils.RESOUCETYPE_SERVICES,
-				PayLoad:   obj,
-			}.PushEvent(&session)
-
-			if err != nil {
-				logrus.Error(err)
-				session.Conn.Close()
-				session = *session.NewSession()
-				services.MonitoringService{
-					EventName: string(event.Type),
-					Resource:  utils.RESOUCETYPE_SERVICES,
-					PayLoad:   obj,
-				}.PushEvent(&session)
-			}
-
-		case <-time.After(30 * time.Minute):
-			logrus.Info("Timeout, restarting event watcher")
-			return nil
-
-		}
-	}
-
-}
-
-func (c ServicesController) Watch() {
-	for {
-		if err := c.runWatcherEventLoop(); err != nil {
-			logrus.Error(err)
-		}
-
-	}
+func (c ServicesController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Core().V1().Services().Informer(), utils.RESOUCETYPE_SERVICES, buffer)
 }
 
 func (c ServicesController) GetOne(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Services(nameSpaceName).Get(ctx.TODO(), name, metav1.GetOptions{})
+	result, err := c.Cache.Factory.Core().V1().Services().Lister().Services(nameSpaceName).Get(name)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -122,13 +56,20 @@ func (c ServicesController) GetOne(context echo.Context, nameSpaceName string, n
 }
 
 func (c ServicesController) Get(context echo.Context, nameSpaceName string) error {
-	var client utils.Client = *utils.NewClient()
-	result, err := client.Clientset.CoreV1().Services(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{})
+	query, err := parseListQuery(context, &v1.Service{})
 	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
 			Message: err.Error(),
 		})
 	}
+	services, err := c.Cache.Factory.Core().V1().Services().Lister().Services(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
+	}
+	result := c.toList(services)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
+	}
 
 	return context.JSON(http.StatusOK, models.Response{
 		Data:         utils.StructToMap(result),
@@ -145,12 +86,13 @@ func (c ServicesController) Create(context echo.Context, nameSpaceName string, s
 		})
 	}
 
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
+	}
 	result, err := client.Clientset.CoreV1().Services(nameSpaceName).Create(ctx.TODO(), service, metav1.CreateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -168,12 +110,13 @@ func (c ServicesController) Update(context echo.Context, nameSpaceName string, s
 		})
 	}
 
-	var client utils.Client = *utils.NewClient()
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
+	}
 	result, err := client.Clientset.CoreV1().Services(nameSpaceName).Update(ctx.TODO(), service, metav1.UpdateOptions{})
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
 	}
 
 	return context.JSON(http.StatusOK, models.Response{
@@ -183,12 +126,13 @@ func (c ServicesController) Update(context echo.Context, nameSpaceName string, s
 }
 
 func (c ServicesController) Delete(context echo.Context, nameSpaceName string, name string) error {
-	var client utils.Client = *utils.NewClient()
-	err := client.Clientset.CoreV1().Services(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	client, err := userClient(context, c.Client)
 	if err != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: err.Error(),
-		})
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
+	}
+	err = client.Clientset.CoreV1().Services(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_SERVICES)
 	}
 
 	return context.JSON(http.StatusNoContent, models.Response{
@@ -196,3 +140,16 @@ func (c ServicesController) Delete(context echo.Context, nameSpaceName string, n
 		ResourceType: utils.RESOUCETYPE_SERVICES,
 	})
 }
+
+func (c ServicesController) toList(services []*v1.Service) *v1.ServiceList {
+	list := &v1.ServiceList{
+		Items: make([]v1.Service, 0, len(services)),
+	}
+	for _, item := range services {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
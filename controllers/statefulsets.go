@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	ctx "context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type StatefulSetsController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c StatefulSetsController) Watch(watchCtx ctx.Context, buffer *services.EventBuffer) {
+	runInformerEventLoop(watchCtx, c.Cache.Factory.Apps().V1().StatefulSets().Informer(), utils.RESOUCETYPE_STATEFULSETS, buffer)
+}
+func (c StatefulSetsController) GetOne(context echo.Context, nameSpaceName string, name string) error {
+	result, err := c.Cache.Factory.Apps().V1().StatefulSets().Lister().StatefulSets(nameSpaceName).Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STATEFULSETS,
+	})
+}
+
+func (c StatefulSetsController) Get(context echo.Context, nameSpaceName string) error {
+	query, err := parseListQuery(context, &v1.StatefulSet{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	statefulSets, err := c.Cache.Factory.Apps().V1().StatefulSets().Lister().StatefulSets(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+	result := c.toList(statefulSets)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STATEFULSETS,
+	})
+}
+
+func (c StatefulSetsController) Create(context echo.Context, nameSpaceName string, statefulSetConfig map[string]interface{}) error {
+	statefulSet := &v1.StatefulSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(statefulSetConfig), statefulSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+	result, err := client.Clientset.AppsV1().StatefulSets(nameSpaceName).Create(ctx.TODO(), statefulSet, metav1.CreateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STATEFULSETS,
+	})
+}
+
+func (c StatefulSetsController) Update(context echo.Context, nameSpaceName string, statefulSetConfig map[string]interface{}) error {
+	statefulSet := &v1.StatefulSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(statefulSetConfig), statefulSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+	result, err := client.Clientset.AppsV1().StatefulSets(nameSpaceName).Update(ctx.TODO(), statefulSet, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STATEFULSETS,
+	})
+}
+
+func (c StatefulSetsController) Delete(context echo.Context, nameSpaceName string, name string) error {
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+	err = client.Clientset.AppsV1().StatefulSets(nameSpaceName).Delete(ctx.TODO(), name, metav1.DeleteOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+
+	return context.JSON(http.StatusNoContent, models.Response{
+		Data:         nil,
+		ResourceType: utils.RESOUCETYPE_STATEFULSETS,
+	})
+}
+
+func (c StatefulSetsController) Restart(context echo.Context, nameSpaceName string, statefulSetConfig map[string]interface{}) error {
+	statefulSet := &v1.StatefulSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(statefulSetConfig), statefulSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	if statefulSet.Spec.Template.ObjectMeta.Annotations == nil {
+		statefulSet.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
+	}
+	statefulSet.Spec.Template.ObjectMeta.Annotations["kubectl.kubernetes.io/restartedAt"] = time.Now().Format(time.RFC3339)
+
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+	result, err := client.Clientset.AppsV1().StatefulSets(nameSpaceName).Update(ctx.TODO(), statefulSet, metav1.UpdateOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STATEFULSETS,
+	})
+}
+
+func (c StatefulSetsController) ReScale(context echo.Context, nameSpaceName string, scale int32, statefulSetConfig map[string]interface{}) error {
+	statefulSet := &v1.StatefulSet{}
+	UnmarshalErr := json.Unmarshal(utils.MapToJson(statefulSetConfig), statefulSet)
+	if UnmarshalErr != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: UnmarshalErr.Error(),
+		})
+	}
+	client, err := userClient(context, c.Client)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+	s, err := client.Clientset.AppsV1().
+		StatefulSets(nameSpaceName).
+		GetScale(ctx.TODO(), statefulSet.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+
+	sc := *s
+	sc.Spec.Replicas = scale
+
+	result, err := client.Clientset.AppsV1().
+		StatefulSets(nameSpaceName).
+		UpdateScale(ctx.TODO(),
+			statefulSet.ObjectMeta.Name, &sc, metav1.UpdateOptions{})
+
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STATEFULSETS)
+	}
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STATEFULSETS,
+	})
+}
+
+func (c StatefulSetsController) toList(statefulSets []*v1.StatefulSet) *v1.StatefulSetList {
+	list := &v1.StatefulSetList{
+		Items: make([]v1.StatefulSet, 0, len(statefulSets)),
+	}
+	for _, item := range statefulSets {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	v1 "k8s.io/api/storage/v1"
+)
+
+type StorageClassesController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (c StorageClassesController) GetOne(context echo.Context, name string) error {
+	result, err := c.Cache.Factory.Storage().V1().StorageClasses().Lister().Get(name)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STORAGECLASSES)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STORAGECLASSES,
+	})
+}
+
+func (c StorageClassesController) Get(context echo.Context) error {
+	query, err := parseListQuery(context, &v1.StorageClass{})
+	if err != nil {
+		return context.JSON(http.StatusBadRequest, models.Response{
+			Message: err.Error(),
+		})
+	}
+	classes, err := c.Cache.Factory.Storage().V1().StorageClasses().Lister().List(query.labelSelector)
+	if err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STORAGECLASSES)
+	}
+	result := c.toList(classes)
+	if err := query.paginate(result); err != nil {
+		return errorResponse(context, err, utils.RESOUCETYPE_STORAGECLASSES)
+	}
+
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(result),
+		ResourceType: utils.RESOUCETYPE_STORAGECLASSES,
+	})
+}
+
+func (c StorageClassesController) toList(classes []*v1.StorageClass) *v1.StorageClassList {
+	list := &v1.StorageClassList{
+		Items: make([]v1.StorageClass, 0, len(classes)),
+	}
+	for _, item := range classes {
+		list.Items = append(list.Items, *item)
+	}
+	sort.Slice(list.Items, func(i, j int) bool {
+		return objectKey(&list.Items[i]) < objectKey(&list.Items[j])
+	})
+	return list
+}
@@ -0,0 +1,21 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type TunnelController struct {
+	Tunnel *services.Tunnel
+}
+
+func (c TunnelController) Status(context echo.Context) error {
+	return context.JSON(http.StatusOK, models.Response{
+		Data:         utils.StructToMap(c.Tunnel.Status()),
+		ResourceType: utils.RESOUCETYPE_TUNNEL,
+	})
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// snapshotChunkSize is how many objects a snapshot message carries at most.
+const snapshotChunkSize = 100
+
+type informerEvent struct {
+	eventType watch.EventType
+	obj       metav1.Object
+}
+
+// runInformerEventLoop registers a handler on a shared informer and pushes
+// every add/update/delete it observes to the outbound event buffer. Informers
+// resume from their last resourceVersion, so nothing is replayed or missed
+// when the underlying watch is restarted. Once the context is done the
+// events already observed are buffered and the loop returns.
+func runInformerEventLoop(ctx context.Context, informer cache.SharedIndexInformer, resource string, buffer *services.EventBuffer) {
+	events := make(chan informerEvent, 1024)
+	push := func(event informerEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			if o, ok := obj.(metav1.Object); ok {
+				push(informerEvent{eventType: watch.Added, obj: redact(o)})
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldMeta, ok := oldObj.(metav1.Object)
+			if !ok {
+				return
+			}
+			newMeta, ok := newObj.(metav1.Object)
+			if !ok {
+				return
+			}
+			// periodic resyncs deliver the same object again
+			if oldMeta.GetResourceVersion() == newMeta.GetResourceVersion() {
+				return
+			}
+			push(informerEvent{eventType: watch.Modified, obj: redact(newMeta)})
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			if o, ok := obj.(metav1.Object); ok {
+				push(informerEvent{eventType: watch.Deleted, obj: redact(o)})
+			}
+		},
+	})
+
+	config := utils.NewConfig()
+	var tracker *services.DeltaTracker
+	var resync <-chan time.Time
+	if config.MonitoringDelta {
+		tracker = services.NewDeltaTracker()
+		ticker := time.NewTicker(config.MonitoringResyncPeriod)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+
+	bufferEvent := func(event informerEvent) {
+		monitoringEvent := services.MonitoringService{
+			NameSpace:   event.obj.GetNamespace(),
+			EventName:   string(event.eventType),
+			Resource:    resource,
+			PayLoad:     event.obj,
+			PayLoadType: models.PAYLOAD_OBJECT,
+		}
+		if tracker != nil {
+			payload, payloadType, changed := tracker.Payload(event.eventType, event.obj)
+			if !changed {
+				return
+			}
+			monitoringEvent.PayLoad = payload
+			monitoringEvent.PayLoadType = payloadType
+		}
+		if err := monitoringEvent.BufferEvent(buffer); err != nil {
+			logrus.Error(err)
+		}
+	}
+
+	for {
+		select {
+		case event := <-events:
+			bufferEvent(event)
+
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-events:
+					bufferEvent(event)
+				default:
+					return
+				}
+			}
+
+		case <-resync:
+			// full state of the resource, so the server can rebuild what it
+			// derived from patches. It is what the patches sent so far were
+			// based on, in order with them.
+			for _, chunk := range tracker.Snapshot(snapshotChunkSize) {
+				err := services.MonitoringService{
+					EventName:   models.EVENT_SNAPSHOT,
+					Resource:    resource,
+					PayLoad:     chunk,
+					PayLoadType: models.PAYLOAD_SNAPSHOT,
+				}.BufferEvent(buffer)
+				if err != nil {
+					logrus.Error(err)
+				}
+			}
+		}
+	}
+}
+
+// redact strips secret values, which are never sent on the monitoring
+// stream.
+func redact(obj metav1.Object) metav1.Object {
+	if secret, ok := obj.(*v1.Secret); ok {
+		return utils.RedactSecret(secret)
+	}
+	return obj
+}
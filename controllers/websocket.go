@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	ctx "context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+const websocketWriteTimeout = 10 * time.Second
+
+// upgrader accepts websockets from any origin: callers authenticate with
+// headers, which the authentication middleware checks before the upgrade.
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+// streamContext ends with the request, or as soon as the server starts
+// shutting down so that long-lived streams do not hold the shutdown back.
+func streamContext(context echo.Context) (ctx.Context, ctx.CancelFunc) {
+	streamCtx, cancel := ctx.WithCancel(context.Request().Context())
+	if shuttingDown := utils.ShuttingDown(streamCtx); shuttingDown != nil {
+		go func() {
+			select {
+			case <-shuttingDown:
+				cancel()
+			case <-streamCtx.Done():
+			}
+		}()
+	}
+	return streamCtx, cancel
+}
@@ -1,85 +1,124 @@
 package controllers
 
 import (
-	ctx "context"
+	"encoding/base64"
 	"net/http"
+	"sort"
 
 	"github.com/kube-carbonara/cluster-agent/models"
 	utils "github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
 	v1 "k8s.io/api/apps/v1"
 	core1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-type WorkLoadController struct{}
+type WorkLoadController struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (c WorkLoadController) Get(context echo.Context, nameSpaceName string) error {
-	var client utils.Client = *utils.NewClient()
-	deployments, deploymentErr := client.Clientset.AppsV1().Deployments(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{})
-	pods, podsError := client.Clientset.CoreV1().Pods(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{})
-	if deploymentErr != nil {
+	query, err := parseListQuery(context, &v1.Deployment{})
+	if err != nil {
 		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: deploymentErr.Error(),
+			Message: err.Error(),
 		})
 	}
+	deployments, deploymentErr := c.listDeployments(nameSpaceName, query)
+	pods, podsError := c.listPods(nameSpaceName, query)
+	if deploymentErr != nil {
+		return errorResponse(context, deploymentErr, utils.WORK_LOAD)
+	}
 	if podsError != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: podsError.Error(),
-		})
+		return errorResponse(context, podsError, utils.WORK_LOAD)
 	}
-	workLoads := getWorkLoad(deployments, pods)
+	result := &models.WorkLoadList{
+		Items: getWorkLoad(deployments, pods),
+	}
+	c.paginate(query, result)
 	return context.JSON(http.StatusOK, models.Response{
-		Data: utils.StructToMap(&models.WorkLoadList{
-			Items: workLoads,
-		}),
+		Data:         utils.StructToMap(result),
 		ResourceType: utils.WORK_LOAD,
 	})
 
 }
 
-func (c WorkLoadController) GetBySelector(context echo.Context, nameSpaceName string, selector string) error {
-	var client utils.Client = *utils.NewClient()
-	deployments, deploymentErr := client.Clientset.AppsV1().Deployments(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{
-		LabelSelector: selector,
-	})
-	pods, podsError := client.Clientset.CoreV1().Pods(nameSpaceName).List(ctx.TODO(), metav1.ListOptions{
-		LabelSelector: selector,
-	})
-	if deploymentErr != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: deploymentErr.Error(),
-		})
+func (c WorkLoadController) listDeployments(nameSpaceName string, query listQuery) (*v1.DeploymentList, error) {
+	deployments, err := c.Cache.Factory.Apps().V1().Deployments().Lister().Deployments(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return nil, err
 	}
-	if podsError != nil {
-		return context.JSON(http.StatusBadRequest, models.Response{
-			Message: podsError.Error(),
-		})
+	list := DeploymentsController{}.toList(deployments)
+	return list, listQuery{fieldSelector: query.fieldSelector}.paginate(list)
+}
+
+func (c WorkLoadController) listPods(nameSpaceName string, query listQuery) (*core1.PodList, error) {
+	pods, err := c.Cache.Factory.Core().V1().Pods().Lister().Pods(nameSpaceName).List(query.labelSelector)
+	if err != nil {
+		return nil, err
 	}
-	workLoads := getWorkLoad(deployments, pods)
-	return context.JSON(http.StatusOK, models.Response{
-		Data: utils.StructToMap(&models.WorkLoadList{
-			Items: workLoads,
-		}),
-		ResourceType: utils.WORK_LOAD,
+	list := PodsController{}.toList(pods)
+	return list, listQuery{fieldSelector: query.fieldSelector}.paginate(list)
+}
+
+// paginate cuts the workloads the way listQuery.paginate cuts typed lists,
+// keyed on the deployment or the standalone pod of each workload.
+func (c WorkLoadController) paginate(query listQuery, list *models.WorkLoadList) {
+	if query.limit == 0 && query.continueKey == "" {
+		return
+	}
+	sort.SliceStable(list.Items, func(i, j int) bool {
+		return workLoadKey(list.Items[i]) < workLoadKey(list.Items[j])
 	})
+
+	page := make([]models.WorkLoad, 0, len(list.Items))
+	remaining := int64(0)
+	lastKey := ""
+	for _, item := range list.Items {
+		key := workLoadKey(item)
+		if query.continueKey != "" && key <= query.continueKey {
+			continue
+		}
+		if query.limit > 0 && len(page) >= query.limit {
+			remaining++
+			continue
+		}
+		page = append(page, item)
+		lastKey = key
+	}
+	list.Items = page
+	if remaining > 0 {
+		list.Continue = base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+		list.RemainingItemCount = &remaining
+	}
+}
+
+func workLoadKey(workLoad models.WorkLoad) string {
+	if workLoad.Deployment != nil {
+		return objectKey(workLoad.Deployment)
+	}
+	if workLoad.Pod != nil {
+		return objectKey(workLoad.Pod)
+	}
+	return ""
 }
 
 func getWorkLoad(deployments *v1.DeploymentList, pods *core1.PodList) []models.WorkLoad {
 	var workLoads []models.WorkLoad
 	var selectors []string
-	for _, v := range deployments.Items {
+	for i := range deployments.Items {
+		v := &deployments.Items[i]
 		selectors = append(selectors, v.Labels["workload.user.cattle.io/workloadselector"])
 		workLoads = append(workLoads, models.WorkLoad{
-			Deployment: &v,
+			Deployment: v,
 			LinkedPods: getLinkedPods(pods, v.Labels["workload.user.cattle.io/workloadselector"]),
 		})
 	}
 
 	otherPods := getUnLinked(pods, selectors)
-	for _, v := range otherPods {
+	for i := range otherPods {
 		workLoads = append(workLoads, models.WorkLoad{
-			Pod: &v,
+			Pod: &otherPods[i],
 		})
 	}
 	return workLoads
@@ -1,96 +1,110 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
-	"io/ioutil"
-	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
-	"github.com/kube-carbonara/cluster-agent/controllers"
-	routers "github.com/kube-carbonara/cluster-agent/routers"
-	"github.com/kube-carbonara/cluster-agent/services"
 	"github.com/kube-carbonara/cluster-agent/utils"
-	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
 )
 
 func init() {
 }
 
 var (
-	addr   string
-	id     string
-	debug  bool
-	appKey string
+	addr        string
+	id          string
+	debug       bool
+	appKey      string
+	kubeConfig  string
+	kubeContext string
+	kubeQPS     float64
+	kubeBurst   int
+	userAgent   string
+
+	eventBufferDir  string
+	eventBufferSize int
+
+	statusAddr string
+
+	drainTimeout time.Duration
 )
 
-func handleRouting(e *echo.Echo) {
-	namespacesRouter := routers.NameSpacesRouter{}
-	podsRouter := routers.PodsRouter{}
-	deplymentRouter := routers.DeploymentsRouter{}
-	serviceRouter := routers.SeviceRouter{}
-	nodeRouter := routers.NodesRouter{}
-	ingressRouter := routers.IngresRouter{}
-	metricsRouter := routers.MetricsRouter{}
-	secretRouter := routers.SecretRouter{}
-	eventRouter := routers.EventsRouter{}
-	workloadRouter := routers.WorkLoadsRouter{}
-	namespacesRouter.Handle(e)
-	podsRouter.Handle(e)
-	deplymentRouter.Handle(e)
-	serviceRouter.Handle(e)
-	nodeRouter.Handle(e)
-	ingressRouter.Handle(e)
-	metricsRouter.Handle(e)
-	secretRouter.Handle(e)
-	eventRouter.Handle(e)
-	workloadRouter.Handle(e)
+// commands are the subcommands of the agent. serve exposes the REST API
+// and runs the watchers, tunnel connects the remotedialer tunnel to the
+// proxy, and all does both in one process. They run until the context is
+// cancelled by SIGINT or SIGTERM, then shut down gracefully.
+var commands = map[string]func(ctx context.Context, config *utils.Config) error{
+	"serve":  serve,
+	"tunnel": tunnel,
+	"all":    all,
 }
 
 func main() {
 	godotenv.Load()
 
-	config := utils.NewConfig()
-	flag.StringVar(&addr, "connect", fmt.Sprintf("ws://%s/connect", config.RemoteProxy), "Address to connect to")
-	flag.StringVar(&id, "id", config.ClientId, "Client ID")
-	flag.StringVar(&appKey, "appKey", config.AppKey, "App Key")
-	flag.BoolVar(&debug, "debug", false, "Debug logging")
-	flag.Parse()
-
-	go controllers.ServicesController{}.Watch()
-	go controllers.PodsController{}.Watch()
-	go controllers.DeploymentsController{}.Watch()
-	go controllers.NameSpacesController{}.Watch()
-	go controllers.NodesController{}.Watch()
-	go controllers.IngressController{}.Watch()
-	go controllers.SecretsController{}.Watch()
-	go controllers.EventsController{}.Watch()
+	// serve stays the default so that running the binary without a
+	// subcommand behaves as before
+	command, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	run, ok := commands[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q, expected one of %s\n", command, strings.Join(commandNames(), ", "))
+		os.Exit(2)
+	}
 
-	e := echo.New()
-	e.GET("/", func(context echo.Context) error {
-		return context.String(http.StatusOK, "Hello, World!")
-	})
-
-	e.GET("/health", func(context echo.Context) error {
-		resp, err := http.Get(fmt.Sprintf("%s://%s", config.RemoteSchema, config.RemoteProxy))
-		if err != nil {
-			log.Fatalln(err)
-			return context.String(http.StatusGatewayTimeout, "error connecting to gateway")
-		}
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			log.Fatalln(err)
-			return context.String(http.StatusGatewayTimeout, "error connecting to gateway")
-		}
+	config := utils.NewConfig()
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	// the tunnel used to be a separate agent logging at debug level by
+	// default, and keeps doing so
+	flags.BoolVar(&debug, "debug", command == "tunnel", "Debug logging")
+	registerFlags(flags, config)
+	flags.Parse(args)
 
-		fmt.Print(string(body) + "\n")
+	if debug {
+		logrus.SetLevel(logrus.DebugLevel)
+	}
+	utils.WarnInsecureProxy(config)
 
-		return context.String(http.StatusOK, "App is running")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, config); err != nil {
+		logrus.Fatal(err)
+	}
+}
 
-	})
-	handleRouting(e)
-	go services.ClusterCacheService{}.PushMetricsUpdatesEventLoop()
+// registerFlags declares the flags shared by every subcommand. Each one
+// defaults to its environment setting.
+func registerFlags(flags *flag.FlagSet, config *utils.Config) {
+	flags.StringVar(&addr, "connect", fmt.Sprintf("%s://%s/connect", utils.ProxyWebsocketScheme(config), config.RemoteProxy), "Address to connect to")
+	flags.StringVar(&id, "id", config.ClientId, "Client ID")
+	flags.StringVar(&appKey, "appKey", config.AppKey, "App Key")
+	flags.StringVar(&kubeConfig, "kubeconfig", "", "Path to a kubeconfig, used instead of the in-cluster config (defaults to KUBECONFIG or ~/.kube/config outside a cluster)")
+	flags.StringVar(&kubeContext, "context", config.KubeContext, "Kubeconfig context to use")
+	flags.Float64Var(&kubeQPS, "kube-qps", float64(config.KubeQPS), "Maximum queries per second to the kubernetes API server")
+	flags.IntVar(&kubeBurst, "kube-burst", config.KubeBurst, "Maximum burst of queries to the kubernetes API server")
+	flags.StringVar(&userAgent, "user-agent", config.KubeUserAgent, "User agent sent to the kubernetes API server")
+	flags.StringVar(&eventBufferDir, "event-buffer-dir", config.EventBufferDir, "Directory persisting undelivered monitoring events across restarts, in memory only when empty")
+	flags.IntVar(&eventBufferSize, "event-buffer-size", config.EventBufferSize, "Maximum number of undelivered monitoring events kept before the oldest are dropped")
+	flags.StringVar(&statusAddr, "status-addr", config.TunnelStatusAddr, "Local address serving /tunnel/status in tunnel mode, empty to disable")
+	flags.DurationVar(&drainTimeout, "drain-timeout", config.ShutdownDrainTimeout, "Time given on shutdown to finish requests, and again to flush undelivered monitoring events")
+}
 
-	e.Logger.Fatal(e.Start(":1323"))
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
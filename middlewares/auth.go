@@ -0,0 +1,175 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	"github.com/labstack/echo/v4"
+)
+
+const IdentityContextKey = "identity"
+
+// ProxySubject is the subject of the requests authenticated with the app
+// key, made by the proxy itself or on behalf of the user it forwards.
+const ProxySubject = "proxy"
+
+type AuthConfig struct {
+	// AppKey is the key shared with the proxy, accepted in the
+	// x-agent-app-key header and granted AppKeyRole.
+	AppKey     string
+	AppKeyRole string
+	// TokenSecret signs the HS256 bearer tokens issued by the proxy.
+	TokenSecret string
+	// Audience, when set, must match the aud claim of bearer tokens.
+	Audience string
+	// SkipPaths are served without authentication. Credentials sent to
+	// them are still checked, and the identity stored when they are valid.
+	SkipPaths []string
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+}
+
+type tokenClaims struct {
+	Subject   string   `json:"sub"`
+	Role      string   `json:"role"`
+	Groups    []string `json:"groups"`
+	Audience  string   `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+}
+
+// Authenticate rejects requests that carry neither the agent app key nor a
+// valid bearer token signed by the proxy, and stores the caller identity in
+// the echo context for the authorization middleware.
+func Authenticate(config AuthConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			for _, path := range config.SkipPaths {
+				if context.Path() == path {
+					if identity, err := authenticate(context.Request(), config); err == nil {
+						context.Set(IdentityContextKey, identity)
+					}
+					return next(context)
+				}
+			}
+
+			identity, err := authenticate(context.Request(), config)
+			if err != nil {
+				return context.JSON(http.StatusUnauthorized, models.Response{
+					Message: err.Error(),
+				})
+			}
+			context.Set(IdentityContextKey, identity)
+			return next(context)
+		}
+	}
+}
+
+func authenticate(r *http.Request, config AuthConfig) (*models.Identity, error) {
+	if authorization := r.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		claims, err := verifyToken(strings.TrimPrefix(authorization, "Bearer "), config)
+		if err != nil {
+			return nil, err
+		}
+		return &models.Identity{
+			Subject: claims.Subject,
+			Role:    claims.Role,
+			User:    claims.Subject,
+			Groups:  claims.Groups,
+		}, nil
+	}
+
+	appKey := r.Header.Get("x-agent-app-key")
+	if appKey != "" && config.AppKey != "" && subtle.ConstantTimeCompare([]byte(appKey), []byte(config.AppKey)) == 1 {
+		// the proxy forwards the user it authenticated
+		return &models.Identity{
+			Subject: ProxySubject,
+			Role:    config.AppKeyRole,
+			User:    r.Header.Get("x-agent-user"),
+			Groups:  splitHeader(r.Header.Get("x-agent-groups")),
+		}, nil
+	}
+	return nil, errors.New("missing or invalid credentials")
+}
+
+// verifyToken checks an HS256 JWT and returns its claims.
+func verifyToken(token string, config AuthConfig) (*tokenClaims, error) {
+	if config.TokenSecret == "" {
+		return nil, errors.New("bearer tokens are not accepted")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed bearer token")
+	}
+
+	var header tokenHeader
+	if err := decodeTokenPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, errors.New("unsupported bearer token algorithm")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed bearer token signature")
+	}
+	mac := hmac.New(sha256.New, []byte(config.TokenSecret))
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, errors.New("invalid bearer token signature")
+	}
+
+	var claims tokenClaims
+	if err := decodeTokenPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	now := time.Now().Unix()
+	if claims.ExpiresAt == 0 || now >= claims.ExpiresAt {
+		return nil, errors.New("bearer token expired")
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return nil, errors.New("bearer token not valid yet")
+	}
+	if config.Audience != "" && claims.Audience != config.Audience {
+		return nil, errors.New("bearer token issued for another audience")
+	}
+	return &claims, nil
+}
+
+func decodeTokenPart(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return errors.New("malformed bearer token")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.New("malformed bearer token")
+	}
+	return nil
+}
+
+func splitHeader(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// CurrentIdentity returns the identity stored by Authenticate, if any.
+func CurrentIdentity(context echo.Context) *models.Identity {
+	identity, _ := context.Get(IdentityContextKey).(*models.Identity)
+	return identity
+}
@@ -0,0 +1,227 @@
+package middlewares
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	"github.com/labstack/echo/v4"
+)
+
+const testSecret = "secret"
+
+func signToken(t *testing.T, header map[string]interface{}, claims map[string]interface{}, secret string) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	unsigned := encode(header) + "." + encode(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyToken(t *testing.T) {
+	now := time.Now().Unix()
+	hs256 := map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+	valid := map[string]interface{}{"sub": "jane", "role": "viewer", "exp": now + 60}
+	config := AuthConfig{TokenSecret: testSecret}
+
+	tests := []struct {
+		name    string
+		token   string
+		config  AuthConfig
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			token:  signToken(t, hs256, valid, testSecret),
+			config: config,
+		},
+		{
+			name:    "tokens disabled",
+			token:   signToken(t, hs256, valid, testSecret),
+			config:  AuthConfig{},
+			wantErr: "bearer tokens are not accepted",
+		},
+		{
+			name:    "expired",
+			token:   signToken(t, hs256, map[string]interface{}{"sub": "jane", "exp": now - 1}, testSecret),
+			config:  config,
+			wantErr: "bearer token expired",
+		},
+		{
+			name:    "expiring now",
+			token:   signToken(t, hs256, map[string]interface{}{"sub": "jane", "exp": now}, testSecret),
+			config:  config,
+			wantErr: "bearer token expired",
+		},
+		{
+			name:    "missing expiry",
+			token:   signToken(t, hs256, map[string]interface{}{"sub": "jane"}, testSecret),
+			config:  config,
+			wantErr: "bearer token expired",
+		},
+		{
+			name:    "not valid yet",
+			token:   signToken(t, hs256, map[string]interface{}{"sub": "jane", "exp": now + 120, "nbf": now + 60}, testSecret),
+			config:  config,
+			wantErr: "bearer token not valid yet",
+		},
+		{
+			name:    "alg none",
+			token:   signToken(t, map[string]interface{}{"alg": "none"}, valid, testSecret),
+			config:  config,
+			wantErr: "unsupported bearer token algorithm",
+		},
+		{
+			name:    "alg none without signature",
+			token:   unsigned(signToken(t, map[string]interface{}{"alg": "none"}, valid, testSecret)),
+			config:  config,
+			wantErr: "unsupported bearer token algorithm",
+		},
+		{
+			name:    "alg RS256",
+			token:   signToken(t, map[string]interface{}{"alg": "RS256"}, valid, testSecret),
+			config:  config,
+			wantErr: "unsupported bearer token algorithm",
+		},
+		{
+			name:    "alg lowercase",
+			token:   signToken(t, map[string]interface{}{"alg": "hs256"}, valid, testSecret),
+			config:  config,
+			wantErr: "unsupported bearer token algorithm",
+		},
+		{
+			name:    "bad signature",
+			token:   signToken(t, hs256, valid, "another secret"),
+			config:  config,
+			wantErr: "invalid bearer token signature",
+		},
+		{
+			name:    "tampered claims",
+			token:   tamper(signToken(t, hs256, valid, testSecret), map[string]interface{}{"sub": "jane", "role": "admin", "exp": now + 60}),
+			config:  config,
+			wantErr: "invalid bearer token signature",
+		},
+		{
+			name:    "signature not base64",
+			token:   signToken(t, hs256, valid, testSecret) + "!",
+			config:  config,
+			wantErr: "malformed bearer token signature",
+		},
+		{
+			name:    "two parts",
+			token:   "a.b",
+			config:  config,
+			wantErr: "malformed bearer token",
+		},
+		{
+			name:    "header not json",
+			token:   base64.RawURLEncoding.EncodeToString([]byte("HS256")) + ".b.c",
+			config:  config,
+			wantErr: "malformed bearer token",
+		},
+		{
+			name:   "audience",
+			token:  signToken(t, hs256, map[string]interface{}{"sub": "jane", "aud": "agent", "exp": now + 60}, testSecret),
+			config: AuthConfig{TokenSecret: testSecret, Audience: "agent"},
+		},
+		{
+			name:    "other audience",
+			token:   signToken(t, hs256, map[string]interface{}{"sub": "jane", "aud": "other", "exp": now + 60}, testSecret),
+			config:  AuthConfig{TokenSecret: testSecret, Audience: "agent"},
+			wantErr: "bearer token issued for another audience",
+		},
+		{
+			name:    "missing audience",
+			token:   signToken(t, hs256, valid, testSecret),
+			config:  AuthConfig{TokenSecret: testSecret, Audience: "agent"},
+			wantErr: "bearer token issued for another audience",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifyToken(tt.token, tt.config)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("verifyToken() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyToken() error = %v", err)
+			}
+			if claims.Subject != "jane" {
+				t.Errorf("subject = %q, want jane", claims.Subject)
+			}
+		})
+	}
+}
+
+// tamper replaces the claims of a signed token, keeping its signature.
+func tamper(token string, claims map[string]interface{}) string {
+	data, _ := json.Marshal(claims)
+	parts := strings.Split(token, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString(data)
+	return strings.Join(parts, ".")
+}
+
+// unsigned strips the signature of a token, as sent with alg none.
+func unsigned(token string) string {
+	return token[:strings.LastIndex(token, ".")+1]
+}
+
+func TestAuthenticateSkipPaths(t *testing.T) {
+	config := AuthConfig{AppKey: "key", AppKeyRole: "viewer", SkipPaths: []string{"/readyz"}}
+	tests := []struct {
+		name         string
+		target       string
+		appKey       string
+		wantCode     int
+		wantIdentity bool
+	}{
+		{name: "skipped without credentials", target: "/readyz", wantCode: http.StatusOK},
+		{name: "skipped with credentials", target: "/readyz", appKey: "key", wantCode: http.StatusOK, wantIdentity: true},
+		{name: "skipped with invalid credentials", target: "/readyz", appKey: "wrong", wantCode: http.StatusOK},
+		{name: "protected without credentials", target: "/nodes", wantCode: http.StatusUnauthorized},
+		{name: "protected with credentials", target: "/nodes", appKey: "key", wantCode: http.StatusOK, wantIdentity: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Use(Authenticate(config))
+			var identity *models.Identity
+			handler := func(context echo.Context) error {
+				identity = CurrentIdentity(context)
+				return context.NoContent(http.StatusOK)
+			}
+			e.GET("/readyz", handler)
+			e.GET("/nodes", handler)
+
+			request := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.appKey != "" {
+				request.Header.Set("x-agent-app-key", tt.appKey)
+			}
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, request)
+			if recorder.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantCode)
+			}
+			if (identity != nil) != tt.wantIdentity {
+				t.Errorf("identity = %+v, want identity %v", identity, tt.wantIdentity)
+			}
+		})
+	}
+}
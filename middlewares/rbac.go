@@ -0,0 +1,151 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	VERB_GET    string = "get"
+	VERB_LIST   string = "list"
+	VERB_CREATE string = "create"
+	VERB_UPDATE string = "update"
+	VERB_DELETE string = "delete"
+	// VERB_REVEAL is needed on top of get or list to see secret values
+	VERB_REVEAL string = "reveal"
+	WILDCARD    string = "*"
+)
+
+// Policy maps a role to the verbs it may use on each resource of the REST
+// API. Resources are named after the route, e.g. "pods" for /:ns/pods/:id
+// and "pods/logs" for /:ns/pods/:id/logs; "*" matches any resource or verb.
+type Policy map[string]map[string][]string
+
+// DefaultPolicy has a read-only viewer, an editor which cannot delete and
+// an admin allowed everything. Only the admin can reveal secret values.
+func DefaultPolicy() Policy {
+	return Policy{
+		"viewer": {
+			WILDCARD: {VERB_GET, VERB_LIST},
+		},
+		"editor": {
+			WILDCARD: {VERB_GET, VERB_LIST, VERB_CREATE, VERB_UPDATE},
+		},
+		"admin": {
+			WILDCARD: {WILDCARD},
+		},
+	}
+}
+
+func LoadPolicy(path string) (Policy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	policy := Policy{}
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("parsing rbac policy %s: %v", path, err)
+	}
+	return policy, nil
+}
+
+func (p Policy) Allows(role string, resource string, verb string) bool {
+	rules, ok := p[role]
+	if !ok {
+		return false
+	}
+	for _, r := range []string{resource, WILDCARD} {
+		for _, v := range rules[r] {
+			if v == verb || v == WILDCARD {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize enforces the policy for the identity set by Authenticate before
+// the request reaches the controllers.
+func Authorize(policy Policy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			identity := CurrentIdentity(context)
+			if identity == nil {
+				return next(context)
+			}
+
+			resource := routeResource(context.Path())
+			// generic routes are checked against the resource they address
+			if name, version := context.Param("resource"), context.Param("version"); name != "" && version != "" {
+				resource = utils.GroupVersionResource(context.Param("group"), version, name).GroupResource().String()
+			}
+			if resource == "" {
+				return next(context)
+			}
+			verbs := []string{routeVerb(context.Request().Method, context.Path())}
+			if resource == revealedResource && context.QueryParam("reveal") == "true" {
+				verbs = append(verbs, VERB_REVEAL)
+			}
+			for _, verb := range verbs {
+				if !policy.Allows(identity.Role, resource, verb) {
+					return context.JSON(http.StatusForbidden, models.Response{
+						ResourceType: resource,
+						Message:      fmt.Sprintf("%s is not allowed to %s %s", identity.Subject, verb, resource),
+					})
+				}
+			}
+			return next(context)
+		}
+	}
+}
+
+// revealedResource is the resource whose values are only returned with
+// reveal=true, to the roles allowed the reveal verb.
+const revealedResource = "secrets"
+
+// routeResource names the resource of a route from its literal segments:
+// /:ns/pods/:id -> pods, /:ns/pods/:id/logs -> pods/logs.
+func routeResource(path string) string {
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || strings.HasPrefix(segment, ":") || segment == WILDCARD {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// connectSubresources open interactive sessions over a websocket GET. Like
+// in the API server they need the create verb, so read-only roles can not
+// get a shell.
+var connectSubresources = []string{"/exec", "/attach", "/connect"}
+
+// routeVerb maps a request to a verb. Reads of a single object or of one of
+// its subresources (/:ns/pods/:id/logs) are get, other reads are list.
+func routeVerb(method string, path string) string {
+	for _, subresource := range connectSubresources {
+		if strings.HasSuffix(path, subresource) {
+			return VERB_CREATE
+		}
+	}
+	switch method {
+	case http.MethodPost:
+		return VERB_CREATE
+	case http.MethodPut, http.MethodPatch:
+		return VERB_UPDATE
+	case http.MethodDelete:
+		return VERB_DELETE
+	}
+	if strings.HasSuffix(path, "/:id") || strings.Contains(path, "/:id/") {
+		return VERB_GET
+	}
+	return VERB_LIST
+}
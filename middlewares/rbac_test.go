@@ -0,0 +1,166 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	"github.com/labstack/echo/v4"
+)
+
+func TestRouteResource(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/:ns/pods", "pods"},
+		{"/:ns/pods/:id", "pods"},
+		{"/:ns/pods/:id/logs", "pods/logs"},
+		{"/:ns/pods/:id/exec", "pods/exec"},
+		{"/:ns/portforwards/:id/connect", "portforwards/connect"},
+		{"/namespaces/:id", "namespaces"},
+		{"/metrics/:resource", "metrics"},
+		{"/tunnel/status", "tunnel/status"},
+		{"/*", ""},
+		{"/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := routeResource(tt.path); got != tt.want {
+				t.Errorf("routeResource(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteVerb(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/:ns/pods", VERB_LIST},
+		{http.MethodGet, "/:ns/pods/:id", VERB_GET},
+		{http.MethodGet, "/:ns/pods/:id/logs", VERB_GET},
+		{http.MethodGet, "/:ns/pods/:id/exec", VERB_CREATE},
+		{http.MethodGet, "/:ns/pods/:id/attach", VERB_CREATE},
+		{http.MethodGet, "/:ns/portforwards/:id/connect", VERB_CREATE},
+		{http.MethodPost, "/:ns/pods", VERB_CREATE},
+		{http.MethodPost, "/:ns/cronjobs/:id/trigger", VERB_CREATE},
+		{http.MethodPut, "/:ns/services", VERB_UPDATE},
+		{http.MethodPatch, "/nodes/:id", VERB_UPDATE},
+		{http.MethodDelete, "/:ns/pods/:id", VERB_DELETE},
+		{http.MethodGet, "/apis/:group/:version/:resource", VERB_LIST},
+		{http.MethodGet, "/:ns/apis/:group/:version/:resource/:id", VERB_GET},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			if got := routeVerb(tt.method, tt.path); got != tt.want {
+				t.Errorf("routeVerb(%q, %q) = %q, want %q", tt.method, tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyAllows(t *testing.T) {
+	policy := DefaultPolicy()
+	policy["logs-reader"] = map[string][]string{
+		"pods":      {VERB_LIST},
+		"pods/logs": {VERB_GET},
+	}
+	policy["deployer"] = map[string][]string{
+		"deployments.apps": {WILDCARD},
+	}
+
+	tests := []struct {
+		role     string
+		resource string
+		verb     string
+		want     bool
+	}{
+		{"viewer", "pods", VERB_GET, true},
+		{"viewer", "pods", VERB_LIST, true},
+		{"viewer", "pods/exec", VERB_CREATE, false},
+		{"viewer", "pods", VERB_DELETE, false},
+		{"editor", "pods/exec", VERB_CREATE, true},
+		{"editor", "deployments", VERB_UPDATE, true},
+		{"editor", "deployments", VERB_DELETE, false},
+		{"admin", "nodes", VERB_DELETE, true},
+		{"admin", "secrets", VERB_REVEAL, true},
+		{"editor", "secrets", VERB_REVEAL, false},
+		{"viewer", "secrets", VERB_REVEAL, false},
+		{"admin", "certificates.cert-manager.io", VERB_CREATE, true},
+		{"logs-reader", "pods", VERB_LIST, true},
+		{"logs-reader", "pods", VERB_GET, false},
+		{"logs-reader", "pods/logs", VERB_GET, true},
+		{"logs-reader", "pods/exec", VERB_CREATE, false},
+		{"deployer", "deployments.apps", VERB_DELETE, true},
+		{"deployer", "deployments", VERB_GET, false},
+		{"unknown", "pods", VERB_GET, false},
+		{"", "pods", VERB_GET, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.role+" "+tt.verb+" "+tt.resource, func(t *testing.T) {
+			if got := policy.Allows(tt.role, tt.resource, tt.verb); got != tt.want {
+				t.Errorf("Allows(%q, %q, %q) = %v, want %v", tt.role, tt.resource, tt.verb, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	policy := Policy{
+		"deployer": {
+			"deployments.apps":             {VERB_GET, VERB_LIST},
+			"configmaps":                   {VERB_LIST},
+			"certificates.cert-manager.io": {VERB_DELETE},
+			"metrics":                      {VERB_LIST},
+			"secrets":                      {VERB_GET, VERB_LIST},
+		},
+		"auditor": {
+			"secrets": {VERB_GET, VERB_REVEAL},
+		},
+	}
+
+	tests := []struct {
+		method string
+		role   string
+		route  string
+		target string
+		want   int
+	}{
+		{http.MethodGet, "deployer", "/:ns/secrets", "/default/secrets", http.StatusOK},
+		{http.MethodGet, "deployer", "/:ns/secrets", "/default/secrets?reveal=true", http.StatusForbidden},
+		{http.MethodGet, "deployer", "/:ns/secrets/:id", "/default/secrets/tls?reveal=true", http.StatusForbidden},
+		{http.MethodGet, "auditor", "/:ns/secrets/:id", "/default/secrets/tls?reveal=true", http.StatusOK},
+		{http.MethodGet, "auditor", "/:ns/secrets", "/default/secrets?reveal=true", http.StatusForbidden},
+		{http.MethodGet, "deployer", "/:ns/apis/:group/:version/:resource", "/default/apis/apps/v1/deployments", http.StatusOK},
+		{http.MethodGet, "deployer", "/apis/:group/:version/:resource/:id", "/apis/apps/v1/deployments/web", http.StatusOK},
+		{http.MethodDelete, "deployer", "/apis/:group/:version/:resource/:id", "/apis/apps/v1/deployments/web", http.StatusForbidden},
+		{http.MethodGet, "deployer", "/apis/:group/:version/:resource", "/apis/core/v1/configmaps", http.StatusOK},
+		{http.MethodGet, "deployer", "/apis/:group/:version/:resource", "/apis/core/v1/services", http.StatusForbidden},
+		{http.MethodDelete, "deployer", "/apis/:group/:version/:resource/:id", "/apis/cert-manager.io/v1/certificates/web", http.StatusOK},
+		{http.MethodGet, "deployer", "/metrics/:resource", "/metrics/nodes", http.StatusOK},
+		{http.MethodGet, "deployer", "/:ns/deployments", "/default/deployments", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			e := echo.New()
+			e.Add(tt.method, tt.route, func(context echo.Context) error {
+				return context.NoContent(http.StatusOK)
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(context echo.Context) error {
+					context.Set(IdentityContextKey, &models.Identity{Subject: "jane", Role: tt.role})
+					return next(context)
+				}
+			}, Authorize(policy))
+
+			recorder := httptest.NewRecorder()
+			e.ServeHTTP(recorder, httptest.NewRequest(tt.method, tt.target, nil))
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.want, recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+// ConfigMapReference is a workload or pod using a config map, with how it
+// uses it: "volume <name>", "env <container>/<variable>" or
+// "envFrom <container>".
+type ConfigMapReference struct {
+	Kind      string   `json:"kind"`
+	Name      string   `json:"name"`
+	NameSpace string   `json:"namespace"`
+	Usages    []string `json:"usages"`
+}
+
+type ConfigMapReferenceList struct {
+	ConfigMap string               `json:"configMap"`
+	NameSpace string               `json:"namespace"`
+	Items     []ConfigMapReference `json:"items"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// ErrorResponse is returned for failed calls to the API server. Reason is
+// the kubernetes status reason, e.g. NotFound or Conflict.
+type ErrorResponse struct {
+	Response
+	Reason  string        `json:"reason"`
+	Details *ErrorDetails `json:"details,omitempty"`
+	Causes  []ErrorCause  `json:"causes,omitempty"`
+}
+
+type ErrorDetails struct {
+	Name  string `json:"name,omitempty"`
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+}
+
+type ErrorCause struct {
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+	Field   string `json:"field,omitempty"`
+}
@@ -0,0 +1,20 @@
+package models
+
+const (
+	HEALTH_OK      string = "ok"
+	HEALTH_FAILING string = "failing"
+)
+
+// HealthCheck is the state of one dependency of the agent. Only critical
+// checks make the agent unready; the others are reported for diagnosis.
+type HealthCheck struct {
+	Healthy  bool                   `json:"healthy"`
+	Critical bool                   `json:"critical"`
+	Message  string                 `json:"message,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+type HealthStatus struct {
+	Status string                 `json:"status"`
+	Checks map[string]HealthCheck `json:"checks"`
+}
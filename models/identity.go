@@ -0,0 +1,11 @@
+package models
+
+// Identity is the authenticated caller of the REST API.
+type Identity struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+	// User and Groups are the end user the proxy acts for, impersonated on
+	// mutating calls to the API server.
+	User   string   `json:"user"`
+	Groups []string `json:"groups"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	batch1 "k8s.io/api/batch/v1"
+	core1 "k8s.io/api/core/v1"
+)
+
+// JobRun is a job started by a cron job along with the pods it ran.
+type JobRun struct {
+	Job  *batch1.Job `json:"job"`
+	Pods []core1.Pod `json:"pods"`
+}
+
+type JobRunList struct {
+	Items []JobRun `json:"items"`
+}
@@ -0,0 +1,28 @@
+package models
+
+const (
+	PAYLOAD_OBJECT      string = "Object"
+	PAYLOAD_MERGE_PATCH string = "MergePatch"
+	PAYLOAD_SNAPSHOT    string = "Snapshot"
+
+	// EVENT_SNAPSHOT is sent next to the watch event types in delta mode
+	EVENT_SNAPSHOT string = "SNAPSHOT"
+)
+
+type MonitoringPatch struct {
+	UID             string                 `json:"uid"`
+	Name            string                 `json:"name"`
+	NameSpace       string                 `json:"namespace"`
+	ResourceVersion string                 `json:"resourceVersion"`
+	Patch           map[string]interface{} `json:"patch"`
+}
+
+// MonitoringSnapshot is one chunk of the full state of a resource. The
+// chunks of a snapshot share its ID and are sent in order, so the server
+// can replace its state once it received the last one.
+type MonitoringSnapshot struct {
+	ID     string                   `json:"id"`
+	Chunk  int                      `json:"chunk"`
+	Chunks int                      `json:"chunks"`
+	Items  []map[string]interface{} `json:"items"`
+}
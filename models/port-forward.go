@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// PortForward is a named tunnel to a port of a pod, either given directly
+// or resolved from a service when the tunnel was opened.
+type PortForward struct {
+	Name      string    `json:"name"`
+	NameSpace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Service   string    `json:"service,omitempty"`
+	Port      int       `json:"port"`
+	LocalPort int       `json:"localPort"`
+	User      string    `json:"user,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type PortForwardList struct {
+	Items []PortForward `json:"items"`
+}
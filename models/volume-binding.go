@@ -0,0 +1,18 @@
+package models
+
+import (
+	core1 "k8s.io/api/core/v1"
+	storage1 "k8s.io/api/storage/v1"
+)
+
+// VolumeBinding follows a claim to the volume bound to it, the storage
+// class provisioning it and the pods mounting it. Volume and StorageClass
+// are nil while the claim is unbound or when the class does not exist;
+// Events are the latest events of the claim, explaining why it is pending.
+type VolumeBinding struct {
+	Claim        *core1.PersistentVolumeClaim `json:"claim"`
+	Volume       *core1.PersistentVolume      `json:"volume"`
+	StorageClass *storage1.StorageClass       `json:"storageClass"`
+	Pods         []core1.Pod                  `json:"pods"`
+	Events       []core1.Event                `json:"events"`
+}
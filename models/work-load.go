@@ -3,6 +3,7 @@ package models
 import (
 	v1 "k8s.io/api/apps/v1"
 	core1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type WorkLoad struct {
@@ -12,5 +13,6 @@ type WorkLoad struct {
 }
 
 type WorkLoadList struct {
-	Items []WorkLoad `json:"items"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkLoad `json:"items"`
 }
@@ -0,0 +1,55 @@
+package routers
+
+import (
+	"strings"
+
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type ConfigMapsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router ConfigMapsRouter) Handle(e *echo.Echo) {
+	configMapController := controllers.ConfigMapsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/:ns/configmaps", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return configMapController.Get(context, ns)
+	})
+
+	e.GET("/:ns/configmaps/:id", func(context echo.Context) error {
+		return configMapController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.GET("/:ns/configmaps/:id/references", func(context echo.Context) error {
+		return configMapController.References(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/configmaps", func(context echo.Context) error {
+		if strings.HasPrefix(context.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+			return configMapController.CreateFromFiles(context, context.Param("ns"))
+		}
+		configMap := utils.JsonBodyToMap(context.Request().Body)
+		return configMapController.Create(context, context.Param("ns"), configMap)
+	})
+
+	e.DELETE("/:ns/configmaps/:id", func(context echo.Context) error {
+		return configMapController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.PUT("/:ns/configmaps", func(context echo.Context) error {
+		configMap := utils.JsonBodyToMap(context.Request().Body)
+		return configMapController.Update(context, context.Param("ns"), configMap)
+	})
+}
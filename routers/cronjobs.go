@@ -0,0 +1,57 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type CronJobsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router CronJobsRouter) Handle(e *echo.Echo) {
+	cronJobController := controllers.CronJobsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/:ns/cronjobs", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return cronJobController.Get(context, ns)
+	})
+
+	e.GET("/:ns/cronjobs/:id", func(context echo.Context) error {
+		return cronJobController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.GET("/:ns/cronjobs/:id/runs", func(context echo.Context) error {
+		return cronJobController.Runs(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/cronjobs", func(context echo.Context) error {
+		cronJob := utils.JsonBodyToMap(context.Request().Body)
+		return cronJobController.Create(context, context.Param("ns"), cronJob)
+	})
+
+	e.POST("/:ns/cronjobs/:id/trigger", func(context echo.Context) error {
+		return cronJobController.Trigger(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.PUT("/:ns/cronjobs/:id/suspend", func(context echo.Context) error {
+		return cronJobController.Suspend(context, context.Param("ns"), context.Param("id"), true)
+	})
+
+	e.PUT("/:ns/cronjobs/:id/resume", func(context echo.Context) error {
+		return cronJobController.Suspend(context, context.Param("ns"), context.Param("id"), false)
+	})
+
+	e.DELETE("/:ns/cronjobs/:id", func(context echo.Context) error {
+		return cronJobController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+}
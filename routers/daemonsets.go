@@ -0,0 +1,50 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type DaemonSetsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router DaemonSetsRouter) Handle(e *echo.Echo) {
+	daemonSetController := controllers.DaemonSetsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/:ns/daemonsets", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return daemonSetController.Get(context, ns)
+	})
+
+	e.GET("/:ns/daemonsets/:id", func(context echo.Context) error {
+		return daemonSetController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/daemonsets", func(context echo.Context) error {
+		daemonSet := utils.JsonBodyToMap(context.Request().Body)
+		return daemonSetController.Create(context, context.Param("ns"), daemonSet)
+	})
+
+	e.DELETE("/:ns/daemonsets/:id", func(context echo.Context) error {
+		return daemonSetController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.PUT("/:ns/daemonsets", func(context echo.Context) error {
+		daemonSet := utils.JsonBodyToMap(context.Request().Body)
+		restartParam := context.QueryParam("restart")
+		if restartParam == "1" {
+			return daemonSetController.Restart(context, context.Param("ns"), daemonSet)
+		}
+		return daemonSetController.Update(context, context.Param("ns"), daemonSet)
+	})
+}
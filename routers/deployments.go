@@ -9,10 +9,15 @@ import (
 )
 
 type DeploymentsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (router DeploymentsRouter) Handle(e *echo.Echo) {
-	deploymentController := controllers.DeploymentsController{}
+	deploymentController := controllers.DeploymentsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/:ns/deployments", func(context echo.Context) error {
 		var ns string
 		if context.Param("ns") == "all" {
@@ -20,14 +25,7 @@ func (router DeploymentsRouter) Handle(e *echo.Echo) {
 		} else {
 			ns = context.Param("ns")
 		}
-
-		selector := context.QueryParam("selector")
-		if selector != "" {
-			return deploymentController.GetBySelector(context, ns, selector)
-		} else {
-			return deploymentController.Get(context, ns)
-		}
-
+		return deploymentController.Get(context, ns)
 	})
 
 	e.GET("/:ns/deployments/:id", func(context echo.Context) error {
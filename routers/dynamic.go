@@ -0,0 +1,58 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type DynamicRouter struct {
+	Client *utils.Client
+}
+
+func (router DynamicRouter) Handle(e *echo.Echo) {
+	dynamicController := controllers.DynamicController{
+		Client: router.Client,
+	}
+	for _, prefix := range []string{"/apis", "/:ns/apis"} {
+		path := prefix + "/:group/:version/:resource"
+		e.GET(path, func(context echo.Context) error {
+			return dynamicController.Get(context, dynamicRequest(context))
+		})
+
+		e.GET(path+"/:id", func(context echo.Context) error {
+			return dynamicController.GetOne(context, dynamicRequest(context), context.Param("id"))
+		})
+
+		e.POST(path, func(context echo.Context) error {
+			object := utils.JsonBodyToMap(context.Request().Body)
+			return dynamicController.Create(context, dynamicRequest(context), object)
+		})
+
+		e.DELETE(path+"/:id", func(context echo.Context) error {
+			return dynamicController.Delete(context, dynamicRequest(context), context.Param("id"))
+		})
+
+		e.PUT(path, func(context echo.Context) error {
+			object := utils.JsonBodyToMap(context.Request().Body)
+			return dynamicController.Update(context, dynamicRequest(context), object)
+		})
+	}
+}
+
+// dynamicRequest reads the resource addressed by a route. Routes under
+// /:ns are namespaced, with all standing for every namespace.
+func dynamicRequest(context echo.Context) controllers.DynamicRequest {
+	request := controllers.DynamicRequest{
+		Resource: utils.GroupVersionResource(context.Param("group"), context.Param("version"), context.Param("resource")),
+	}
+	for _, name := range context.ParamNames() {
+		if name == "ns" {
+			request.Namespaced = true
+		}
+	}
+	if request.Namespaced && context.Param("ns") != "all" {
+		request.NameSpace = context.Param("ns")
+	}
+	return request
+}
@@ -2,13 +2,20 @@ package routers
 
 import (
 	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
 )
 
-type EventsRouter struct{}
+type EventsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (router EventsRouter) Handle(e *echo.Echo) {
-	eventController := controllers.EventsController{}
+	eventController := controllers.EventsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/:ns/events", func(context echo.Context) error {
 		var ns string
 		if context.Param("ns") == "all" {
@@ -0,0 +1,40 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type HealthRouter struct {
+	Client          *utils.Client
+	Cache           *utils.InformerCache
+	Session         *utils.Session
+	Buffer          *services.EventBuffer
+	Tunnel          *services.Tunnel
+	RequireIdentity bool
+}
+
+func (router HealthRouter) Handle(e *echo.Echo) {
+	healthController := controllers.HealthController{
+		Client:          router.Client,
+		Cache:           router.Cache,
+		Session:         router.Session,
+		Buffer:          router.Buffer,
+		Tunnel:          router.Tunnel,
+		RequireIdentity: router.RequireIdentity,
+	}
+	e.GET("/livez", func(context echo.Context) error {
+		return healthController.Live(context)
+	})
+
+	e.GET("/readyz", func(context echo.Context) error {
+		return healthController.Ready(context)
+	})
+
+	// kept for the probes configured before /livez and /readyz
+	e.GET("/health", func(context echo.Context) error {
+		return healthController.Ready(context)
+	})
+}
@@ -7,10 +7,15 @@ import (
 )
 
 type IngresRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (router IngresRouter) Handle(e *echo.Echo) {
-	ingressController := controllers.IngressController{}
+	ingressController := controllers.IngressController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 
 	e.GET("/:ns/ingress", func(context echo.Context) error {
 		var ns string
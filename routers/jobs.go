@@ -0,0 +1,41 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type JobsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router JobsRouter) Handle(e *echo.Echo) {
+	jobController := controllers.JobsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/:ns/jobs", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return jobController.Get(context, ns)
+	})
+
+	e.GET("/:ns/jobs/:id", func(context echo.Context) error {
+		return jobController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/jobs", func(context echo.Context) error {
+		job := utils.JsonBodyToMap(context.Request().Body)
+		return jobController.Create(context, context.Param("ns"), job)
+	})
+
+	e.DELETE("/:ns/jobs/:id", func(context echo.Context) error {
+		return jobController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+}
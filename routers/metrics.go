@@ -6,10 +6,16 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-type MetricsRouter struct{}
+type MetricsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (router MetricsRouter) Handle(e *echo.Echo) {
-	metricsController := controllers.MetricsController{}
+	metricsController := controllers.MetricsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 
 	e.GET("/metrics/:resource", func(context echo.Context) error {
 		switch context.Param("resource") {
@@ -2,14 +2,20 @@ package routers
 
 import (
 	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
 )
 
 type NameSpacesRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (router NameSpacesRouter) Handle(e *echo.Echo) {
-	nameSpacesController := controllers.NameSpacesController{}
+	nameSpacesController := controllers.NameSpacesController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/namespaces", func(context echo.Context) error {
 		return nameSpacesController.Get(context)
 	})
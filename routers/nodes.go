@@ -6,10 +6,16 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-type NodesRouter struct{}
+type NodesRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (router NodesRouter) Handle(e *echo.Echo) {
-	nodesController := controllers.NodesController{}
+	nodesController := controllers.NodesController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/nodes", func(context echo.Context) error {
 		return nodesController.Get(context)
 	})
@@ -0,0 +1,50 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type PersistentVolumeClaimsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router PersistentVolumeClaimsRouter) Handle(e *echo.Echo) {
+	claimController := controllers.PersistentVolumeClaimsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/:ns/persistentvolumeclaims", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return claimController.Get(context, ns)
+	})
+
+	e.GET("/:ns/persistentvolumeclaims/:id", func(context echo.Context) error {
+		return claimController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.GET("/:ns/persistentvolumeclaims/:id/binding", func(context echo.Context) error {
+		return claimController.Binding(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/persistentvolumeclaims", func(context echo.Context) error {
+		claim := utils.JsonBodyToMap(context.Request().Body)
+		return claimController.Create(context, context.Param("ns"), claim)
+	})
+
+	e.DELETE("/:ns/persistentvolumeclaims/:id", func(context echo.Context) error {
+		return claimController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.PUT("/:ns/persistentvolumeclaims", func(context echo.Context) error {
+		claim := utils.JsonBodyToMap(context.Request().Body)
+		return claimController.Update(context, context.Param("ns"), claim)
+	})
+}
@@ -0,0 +1,26 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type PersistentVolumesRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router PersistentVolumesRouter) Handle(e *echo.Echo) {
+	volumesController := controllers.PersistentVolumesController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/persistentvolumes", func(context echo.Context) error {
+		return volumesController.Get(context)
+	})
+
+	e.GET("/persistentvolumes/:id", func(context echo.Context) error {
+		return volumesController.GetOne(context, context.Param("id"))
+	})
+}
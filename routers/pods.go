@@ -1,18 +1,21 @@
 package routers
 
 import (
-	"fmt"
-
 	controllers "github.com/kube-carbonara/cluster-agent/controllers"
 	"github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
 )
 
 type PodsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (router PodsRouter) Handle(e *echo.Echo) {
-	podsController := controllers.PodsController{}
+	podsController := controllers.PodsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/:ns/pods", func(context echo.Context) error {
 		var ns string
 		if context.Param("ns") == "all" {
@@ -20,20 +23,25 @@ func (router PodsRouter) Handle(e *echo.Echo) {
 		} else {
 			ns = context.Param("ns")
 		}
-		selector := context.QueryParam("selector")
-		if selector != "" {
-			fmt.Printf("Selector %s \n", selector)
-			return podsController.GetBySelector(context, ns, selector)
-		} else {
-			return podsController.Get(context, ns)
-		}
-
+		return podsController.Get(context, ns)
 	})
 
 	e.GET("/:ns/pods/:id", func(context echo.Context) error {
 		return podsController.GetOne(context, context.Param("ns"), context.Param("id"))
 	})
 
+	e.GET("/:ns/pods/:id/logs", func(context echo.Context) error {
+		return podsController.Logs(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.GET("/:ns/pods/:id/exec", func(context echo.Context) error {
+		return podsController.Exec(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.GET("/:ns/pods/:id/attach", func(context echo.Context) error {
+		return podsController.Attach(context, context.Param("ns"), context.Param("id"))
+	})
+
 	e.POST("/:ns/pods", func(context echo.Context) error {
 		deployment := utils.JsonBodyToMap(context.Request().Body)
 		return podsController.Create(context, context.Param("ns"), deployment)
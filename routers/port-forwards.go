@@ -0,0 +1,48 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type PortForwardsRouter struct {
+	Client   *utils.Client
+	Cache    *utils.InformerCache
+	Forwards *services.PortForwards
+}
+
+func (router PortForwardsRouter) Handle(e *echo.Echo) {
+	portForwardsController := controllers.PortForwardsController{
+		Client:   router.Client,
+		Cache:    router.Cache,
+		Forwards: router.Forwards,
+	}
+	e.GET("/:ns/portforwards", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return portForwardsController.Get(context, ns)
+	})
+
+	e.GET("/:ns/portforwards/:id", func(context echo.Context) error {
+		return portForwardsController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.GET("/:ns/portforwards/:id/connect", func(context echo.Context) error {
+		return portForwardsController.Connect(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/portforwards", func(context echo.Context) error {
+		forward := utils.JsonBodyToMap(context.Request().Body)
+		return portForwardsController.Create(context, context.Param("ns"), forward)
+	})
+
+	e.DELETE("/:ns/portforwards/:id", func(context echo.Context) error {
+		return portForwardsController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+}
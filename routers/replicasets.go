@@ -0,0 +1,55 @@
+package routers
+
+import (
+	"strconv"
+
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type ReplicaSetsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router ReplicaSetsRouter) Handle(e *echo.Echo) {
+	replicaSetController := controllers.ReplicaSetsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/:ns/replicasets", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return replicaSetController.Get(context, ns)
+	})
+
+	e.GET("/:ns/replicasets/:id", func(context echo.Context) error {
+		return replicaSetController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/replicasets", func(context echo.Context) error {
+		replicaSet := utils.JsonBodyToMap(context.Request().Body)
+		return replicaSetController.Create(context, context.Param("ns"), replicaSet)
+	})
+
+	e.DELETE("/:ns/replicasets/:id", func(context echo.Context) error {
+		return replicaSetController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.PUT("/:ns/replicasets", func(context echo.Context) error {
+		replicaSet := utils.JsonBodyToMap(context.Request().Body)
+		scaleParam := context.QueryParam("scale")
+		if scaleParam != "" {
+			scale, err := strconv.ParseInt(scaleParam, 0, 32)
+			if err == nil {
+				return replicaSetController.ReScale(context, context.Param("ns"), int32(scale), replicaSet)
+			}
+		}
+		return replicaSetController.Update(context, context.Param("ns"), replicaSet)
+	})
+}
@@ -6,10 +6,16 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-type SecretRouter struct{}
+type SecretRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (router SecretRouter) Handle(e *echo.Echo) {
-	secretController := controllers.SecretsController{}
+	secretController := controllers.SecretsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/:ns/secrets", func(context echo.Context) error {
 		var ns string
 		if context.Param("ns") == "all" {
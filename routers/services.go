@@ -6,10 +6,16 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-type SeviceRouter struct{}
+type SeviceRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
 
 func (router SeviceRouter) Handle(e *echo.Echo) {
-	serviceController := controllers.ServicesController{}
+	serviceController := controllers.ServicesController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/:ns/services", func(context echo.Context) error {
 		var ns string
 		if context.Param("ns") == "all" {
@@ -0,0 +1,59 @@
+package routers
+
+import (
+	"strconv"
+
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type StatefulSetsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router StatefulSetsRouter) Handle(e *echo.Echo) {
+	statefulSetController := controllers.StatefulSetsController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/:ns/statefulsets", func(context echo.Context) error {
+		var ns string
+		if context.Param("ns") == "all" {
+			ns = ""
+		} else {
+			ns = context.Param("ns")
+		}
+		return statefulSetController.Get(context, ns)
+	})
+
+	e.GET("/:ns/statefulsets/:id", func(context echo.Context) error {
+		return statefulSetController.GetOne(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.POST("/:ns/statefulsets", func(context echo.Context) error {
+		statefulSet := utils.JsonBodyToMap(context.Request().Body)
+		return statefulSetController.Create(context, context.Param("ns"), statefulSet)
+	})
+
+	e.DELETE("/:ns/statefulsets/:id", func(context echo.Context) error {
+		return statefulSetController.Delete(context, context.Param("ns"), context.Param("id"))
+	})
+
+	e.PUT("/:ns/statefulsets", func(context echo.Context) error {
+		statefulSet := utils.JsonBodyToMap(context.Request().Body)
+		restartParam := context.QueryParam("restart")
+		scaleParam := context.QueryParam("scale")
+		if restartParam == "1" {
+			return statefulSetController.Restart(context, context.Param("ns"), statefulSet)
+		}
+		if scaleParam != "" {
+			scale, err := strconv.ParseInt(scaleParam, 0, 32)
+			if err == nil {
+				return statefulSetController.ReScale(context, context.Param("ns"), int32(scale), statefulSet)
+			}
+		}
+		return statefulSetController.Update(context, context.Param("ns"), statefulSet)
+	})
+}
@@ -0,0 +1,26 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+)
+
+type StorageClassesRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
+}
+
+func (router StorageClassesRouter) Handle(e *echo.Echo) {
+	classesController := controllers.StorageClassesController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
+	e.GET("/storageclasses", func(context echo.Context) error {
+		return classesController.Get(context)
+	})
+
+	e.GET("/storageclasses/:id", func(context echo.Context) error {
+		return classesController.GetOne(context, context.Param("id"))
+	})
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	services "github.com/kube-carbonara/cluster-agent/services"
+	"github.com/labstack/echo/v4"
+)
+
+type TunnelRouter struct {
+	Tunnel *services.Tunnel
+}
+
+func (router TunnelRouter) Handle(e *echo.Echo) {
+	tunnelController := controllers.TunnelController{
+		Tunnel: router.Tunnel,
+	}
+	e.GET("/tunnel/status", func(context echo.Context) error {
+		return tunnelController.Status(context)
+	})
+}
@@ -2,14 +2,20 @@ package routers
 
 import (
 	controllers "github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/utils"
 	"github.com/labstack/echo/v4"
 )
 
 type WorkLoadsRouter struct {
+	Client *utils.Client
+	Cache  *utils.InformerCache
 }
 
 func (router WorkLoadsRouter) Handle(e *echo.Echo) {
-	workLoadController := controllers.WorkLoadController{}
+	workLoadController := controllers.WorkLoadController{
+		Client: router.Client,
+		Cache:  router.Cache,
+	}
 	e.GET("/:ns/workloads", func(context echo.Context) error {
 		var ns string
 		if context.Param("ns") == "all" {
@@ -17,13 +23,6 @@ func (router WorkLoadsRouter) Handle(e *echo.Echo) {
 		} else {
 			ns = context.Param("ns")
 		}
-
-		selector := context.QueryParam("selector")
-		if selector != "" {
-			return workLoadController.GetBySelector(context, ns, selector)
-		} else {
-			return workLoadController.Get(context, ns)
-		}
-
+		return workLoadController.Get(context, ns)
 	})
 }
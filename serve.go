@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/kube-carbonara/cluster-agent/controllers"
+	"github.com/kube-carbonara/cluster-agent/middlewares"
+	routers "github.com/kube-carbonara/cluster-agent/routers"
+	"github.com/kube-carbonara/cluster-agent/services"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+func handleRouting(e *echo.Echo, client *utils.Client, cache *utils.InformerCache, forwards *services.PortForwards) {
+	namespacesRouter := routers.NameSpacesRouter{Client: client, Cache: cache}
+	podsRouter := routers.PodsRouter{Client: client, Cache: cache}
+	deplymentRouter := routers.DeploymentsRouter{Client: client, Cache: cache}
+	statefulSetsRouter := routers.StatefulSetsRouter{Client: client, Cache: cache}
+	daemonSetsRouter := routers.DaemonSetsRouter{Client: client, Cache: cache}
+	replicaSetsRouter := routers.ReplicaSetsRouter{Client: client, Cache: cache}
+	jobsRouter := routers.JobsRouter{Client: client, Cache: cache}
+	cronJobsRouter := routers.CronJobsRouter{Client: client, Cache: cache}
+	serviceRouter := routers.SeviceRouter{Client: client, Cache: cache}
+	nodeRouter := routers.NodesRouter{Client: client, Cache: cache}
+	ingressRouter := routers.IngresRouter{Client: client, Cache: cache}
+	metricsRouter := routers.MetricsRouter{Client: client, Cache: cache}
+	secretRouter := routers.SecretRouter{Client: client, Cache: cache}
+	configMapsRouter := routers.ConfigMapsRouter{Client: client, Cache: cache}
+	claimsRouter := routers.PersistentVolumeClaimsRouter{Client: client, Cache: cache}
+	volumesRouter := routers.PersistentVolumesRouter{Client: client, Cache: cache}
+	storageClassesRouter := routers.StorageClassesRouter{Client: client, Cache: cache}
+	eventRouter := routers.EventsRouter{Client: client, Cache: cache}
+	workloadRouter := routers.WorkLoadsRouter{Client: client, Cache: cache}
+	portForwardsRouter := routers.PortForwardsRouter{Client: client, Cache: cache, Forwards: forwards}
+	namespacesRouter.Handle(e)
+	podsRouter.Handle(e)
+	deplymentRouter.Handle(e)
+	statefulSetsRouter.Handle(e)
+	daemonSetsRouter.Handle(e)
+	replicaSetsRouter.Handle(e)
+	jobsRouter.Handle(e)
+	cronJobsRouter.Handle(e)
+	serviceRouter.Handle(e)
+	nodeRouter.Handle(e)
+	ingressRouter.Handle(e)
+	metricsRouter.Handle(e)
+	secretRouter.Handle(e)
+	configMapsRouter.Handle(e)
+	claimsRouter.Handle(e)
+	volumesRouter.Handle(e)
+	storageClassesRouter.Handle(e)
+	eventRouter.Handle(e)
+	workloadRouter.Handle(e)
+	portForwardsRouter.Handle(e)
+	routers.DynamicRouter{Client: client}.Handle(e)
+}
+
+// server is the REST API along with the watchers and loops feeding the
+// monitoring channel of the proxy.
+type server struct {
+	echo     *echo.Echo
+	session  *utils.Session
+	buffer   *services.EventBuffer
+	forwards *services.PortForwards
+	// background counts the watchers and loops to wait for on shutdown
+	background sync.WaitGroup
+	// sender is done once the event buffer stopped sending
+	sender sync.WaitGroup
+	// cancel stops everything started by newServer
+	cancel context.CancelFunc
+	// shuttingDown ends the streaming requests on shutdown
+	shuttingDown chan struct{}
+}
+
+// serve exposes the REST API on :1323 and streams the watcher events to
+// the monitoring channel of the proxy.
+func serve(ctx context.Context, config *utils.Config) error {
+	return newServer(ctx, config, nil).run(ctx)
+}
+
+// newServer connects to the cluster, starts the watchers and the metrics
+// loop, and returns the REST API ready to be run. Everything started stops
+// with the context, or when the server shuts down. The tunnel, when given,
+// is served and reported on by the REST API.
+func newServer(parent context.Context, config *utils.Config, t *services.Tunnel) *server {
+	ctx, cancel := context.WithCancel(parent)
+	client, err := utils.NewClient(utils.ClientOptions{
+		KubeConfig:  kubeConfig,
+		KubeContext: kubeContext,
+		QPS:         float32(kubeQPS),
+		Burst:       kubeBurst,
+		UserAgent:   userAgent,
+	})
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	cache := utils.NewInformerCache(client, 0)
+	cache.Start(ctx.Done())
+
+	dialer, err := utils.NewProxyDialer(config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	proxyClient, err := utils.NewProxyHTTPClient(config)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	s := &server{
+		session: &utils.Session{
+			Host:    config.RemoteProxy,
+			Channel: "monitoring",
+			Scheme:  utils.ProxyWebsocketScheme(config),
+			Dialer:  dialer,
+		},
+		forwards:     services.NewPortForwards(),
+		cancel:       cancel,
+		shuttingDown: make(chan struct{}),
+	}
+	s.buffer, err = services.NewEventBuffer(eventBufferDir, eventBufferSize)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	s.sender.Add(1)
+	go func() {
+		defer s.sender.Done()
+		// connect before the first event so that readiness reports the
+		// session as soon as the proxy is reachable
+		if err := s.session.Connect(ctx); err != nil {
+			return
+		}
+		s.buffer.Run(ctx, s.session)
+	}()
+
+	s.start(func() { controllers.ServicesController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.PodsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.DeploymentsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.StatefulSetsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.DaemonSetsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.ReplicaSetsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.JobsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.CronJobsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.NameSpacesController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.NodesController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.IngressController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.SecretsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.ConfigMapsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.PersistentVolumeClaimsController{Cache: cache}.Watch(ctx, s.buffer) })
+	s.start(func() { controllers.EventsController{Cache: cache}.Watch(ctx, s.buffer) })
+	for _, resource := range config.MonitoringResources {
+		resource := resource
+		s.start(func() { controllers.DynamicController{Client: client}.Watch(ctx, s.buffer, resource) })
+	}
+
+	policy := middlewares.DefaultPolicy()
+	if config.RbacPolicyFile != "" {
+		policy, err = middlewares.LoadPolicy(config.RbacPolicyFile)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	// tokens are signed with the app key unless a secret of their own is
+	// set, the key given by -appKey included
+	tokenSecret := config.AuthTokenSecret
+	if tokenSecret == "" {
+		tokenSecret = appKey
+	}
+
+	e := echo.New()
+	e.Server.BaseContext = func(net.Listener) context.Context {
+		return utils.WithShuttingDown(context.Background(), s.shuttingDown)
+	}
+	e.Use(middlewares.Authenticate(middlewares.AuthConfig{
+		AppKey:      appKey,
+		AppKeyRole:  config.AuthAppKeyRole,
+		TokenSecret: tokenSecret,
+		Audience:    config.AuthTokenAudience,
+		SkipPaths:   []string{"/", "/health", "/livez", "/readyz"},
+	}))
+	e.Use(middlewares.Authorize(policy))
+	e.GET("/", func(context echo.Context) error {
+		return context.String(http.StatusOK, "Hello, World!")
+	})
+
+	routers.HealthRouter{
+		Client:          client,
+		Cache:           cache,
+		Session:         s.session,
+		Buffer:          s.buffer,
+		Tunnel:          t,
+		RequireIdentity: true,
+	}.Handle(e)
+	if t != nil {
+		routers.TunnelRouter{Tunnel: t}.Handle(e)
+	}
+	handleRouting(e, client, cache, s.forwards)
+	s.start(func() {
+		services.ClusterCacheService{Client: client, HTTPClient: proxyClient}.PushMetricsUpdatesEventLoop(ctx)
+	})
+
+	s.echo = e
+	return s
+}
+
+func (s *server) start(run func()) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		run()
+	}()
+}
+
+// run serves the REST API until the context is done, then shuts down.
+func (s *server) run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.echo.Start(":1323")
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		logrus.Info("shutting down")
+	case err = <-errCh:
+	}
+	s.shutdown()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// shutdown stops the watchers and the loops, ends the streams, stops
+// taking requests and waits for the running ones within the drain timeout,
+// then flushes the buffered events to the proxy within another.
+func (s *server) shutdown() {
+	s.cancel()
+	close(s.shuttingDown)
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelDrain()
+	if err := s.echo.Shutdown(drainCtx); err != nil {
+		logrus.Error("shutting down the REST API: ", err)
+	}
+	s.forwards.StopAll()
+	s.background.Wait()
+	s.sender.Wait()
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancelFlush()
+	if err := s.buffer.Drain(flushCtx, s.session); err != nil {
+		logrus.Error("flushing monitoring events: ", err)
+	}
+	s.session.Close()
+}
@@ -16,9 +16,14 @@ import (
 	"k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
 
-type ClusterCacheService struct{}
+// ClusterCacheService pushes the cluster metrics to the proxy. HTTPClient
+// defaults to http.DefaultClient.
+type ClusterCacheService struct {
+	Client     *utils.Client
+	HTTPClient *http.Client
+}
 
-func (c ClusterCacheService) PushMetricsUpdates() {
+func (c ClusterCacheService) PushMetricsUpdates(pushCtx ctx.Context) {
 	metrics, err := c.ClusterMetrics()
 	if err != nil {
 		logrus.Error(err)
@@ -32,8 +37,11 @@ func (c ClusterCacheService) PushMetricsUpdates() {
 	}
 
 	config := utils.NewConfig()
-	client := &http.Client{}
-	r, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s://%s/clusters/updatemetrics/%s", config.RemoteSchema, config.RemoteProxy, config.ClientId), bytes.NewBuffer(jsonReq))
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r, err := http.NewRequestWithContext(pushCtx, http.MethodPut, fmt.Sprintf("%s://%s/clusters/updatemetrics/%s", config.RemoteSchema, config.RemoteProxy, config.ClientId), bytes.NewBuffer(jsonReq))
 	if err != nil {
 		logrus.Error(err)
 		return
@@ -41,23 +49,23 @@ func (c ClusterCacheService) PushMetricsUpdates() {
 	r.Header.Add("Content-Type", "application/json; charset=utf-8")
 	r.Header.Add("x-agent", config.ClientId)
 	r.Header.Add("x-agent-app-key", config.AppKey)
-	resp, _ := client.Do(r)
+	resp, err := client.Do(r)
 	if err != nil {
 		logrus.Error(err)
 		return
 	}
+	defer resp.Body.Close()
 	fmt.Print(resp)
 
 }
 
 func (c ClusterCacheService) ClusterMetrics() (models.ClusterMetricsCache, error) {
-	var client utils.Client = *utils.NewClient()
-	metrics, err := client.MetricsV1beta1.NodeMetricses().List(ctx.TODO(), metav1.ListOptions{})
+	metrics, err := c.Client.MetricsV1beta1.NodeMetricses().List(ctx.TODO(), metav1.ListOptions{})
 	if err != nil {
 		logrus.Error(err)
 		return models.ClusterMetricsCache{}, err
 	}
-	nodes, err := client.Clientset.CoreV1().Nodes().List(ctx.TODO(), metav1.ListOptions{})
+	nodes, err := c.Client.Clientset.CoreV1().Nodes().List(ctx.TODO(), metav1.ListOptions{})
 	if err != nil {
 		logrus.Error(err)
 		return models.ClusterMetricsCache{}, err
@@ -95,8 +103,17 @@ func (c ClusterCacheService) RowClusterMetrics(metrics []v1beta1.NodeMetrics, no
 	return row
 }
 
-func (c ClusterCacheService) PushMetricsUpdatesEventLoop() {
-	for range time.Tick(time.Minute * 1) {
-		c.PushMetricsUpdates()
+// PushMetricsUpdatesEventLoop pushes the metrics every minute until the
+// context is done.
+func (c ClusterCacheService) PushMetricsUpdatesEventLoop(loopCtx ctx.Context) {
+	ticker := time.NewTicker(time.Minute * 1)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-loopCtx.Done():
+			return
+		case <-ticker.C:
+			c.PushMetricsUpdates(loopCtx)
+		}
 	}
 }
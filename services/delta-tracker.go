@@ -0,0 +1,100 @@
+package services
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// DeltaTracker remembers the last object pushed for every UID of a resource
+// so that modifications can be sent as JSON merge patches instead of full
+// objects. managedFields are never sent in this mode.
+type DeltaTracker struct {
+	last map[types.UID]map[string]interface{}
+}
+
+func NewDeltaTracker() *DeltaTracker {
+	return &DeltaTracker{
+		last: map[types.UID]map[string]interface{}{},
+	}
+}
+
+// Payload returns what to push for a watcher event along with its payload
+// type. It returns false when a modification carries no change.
+func (d *DeltaTracker) Payload(eventType watch.EventType, obj metav1.Object) (interface{}, string, bool) {
+	current := d.toMap(obj)
+	switch eventType {
+	case watch.Deleted:
+		delete(d.last, obj.GetUID())
+		return current, models.PAYLOAD_OBJECT, true
+	case watch.Modified:
+		last, ok := d.last[obj.GetUID()]
+		d.last[obj.GetUID()] = current
+		if !ok {
+			return current, models.PAYLOAD_OBJECT, true
+		}
+		patch := utils.CreateMergePatch(last, current)
+		if len(patch) == 0 {
+			return nil, "", false
+		}
+		return models.MonitoringPatch{
+			UID:             string(obj.GetUID()),
+			Name:            obj.GetName(),
+			NameSpace:       obj.GetNamespace(),
+			ResourceVersion: obj.GetResourceVersion(),
+			Patch:           patch,
+		}, models.PAYLOAD_MERGE_PATCH, true
+	default:
+		d.last[obj.GetUID()] = current
+		return current, models.PAYLOAD_OBJECT, true
+	}
+}
+
+// Snapshot returns the objects the following patches are based on, in
+// chunks of at most chunkSize objects. It is the state the server was sent,
+// so it must be taken by the loop handling the events: the informer store
+// may already be ahead of the events still queued. An empty resource gives
+// one empty chunk, so the server still clears its state.
+func (d *DeltaTracker) Snapshot(chunkSize int) []models.MonitoringSnapshot {
+	uids := make([]string, 0, len(d.last))
+	for uid := range d.last {
+		uids = append(uids, string(uid))
+	}
+	sort.Strings(uids)
+
+	id := strconv.FormatInt(time.Now().UnixNano(), 10)
+	chunks := []models.MonitoringSnapshot{}
+	for start := 0; start == 0 || start < len(uids); start += chunkSize {
+		end := start + chunkSize
+		if end > len(uids) {
+			end = len(uids)
+		}
+		chunk := models.MonitoringSnapshot{
+			ID:    id,
+			Chunk: len(chunks),
+			Items: make([]map[string]interface{}, 0, end-start),
+		}
+		for _, uid := range uids[start:end] {
+			chunk.Items = append(chunk.Items, d.last[types.UID(uid)])
+		}
+		chunks = append(chunks, chunk)
+	}
+	for i := range chunks {
+		chunks[i].Chunks = len(chunks)
+	}
+	return chunks
+}
+
+func (d *DeltaTracker) toMap(obj metav1.Object) map[string]interface{} {
+	result := utils.StructToMap(obj)
+	if metadata, ok := result["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+	}
+	return result
+}
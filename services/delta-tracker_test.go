@@ -0,0 +1,127 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func testConfigMap(resourceVersion string, data map[string]string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			UID:             "uid-1",
+			Name:            "settings",
+			Namespace:       "default",
+			ResourceVersion: resourceVersion,
+			ManagedFields:   []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Data: data,
+	}
+}
+
+func TestDeltaTrackerPayload(t *testing.T) {
+	tracker := NewDeltaTracker()
+
+	payload, payloadType, ok := tracker.Payload(watch.Added, testConfigMap("1", map[string]string{"a": "1", "b": "2"}))
+	if !ok || payloadType != models.PAYLOAD_OBJECT {
+		t.Fatalf("added: got %q, %v", payloadType, ok)
+	}
+	metadata := payload.(map[string]interface{})["metadata"].(map[string]interface{})
+	if _, found := metadata["managedFields"]; found {
+		t.Errorf("added: managedFields not stripped")
+	}
+
+	if _, _, ok := tracker.Payload(watch.Modified, testConfigMap("1", map[string]string{"a": "1", "b": "2"})); ok {
+		t.Errorf("unchanged modification should not be pushed")
+	}
+
+	payload, payloadType, ok = tracker.Payload(watch.Modified, testConfigMap("2", map[string]string{"a": "3"}))
+	if !ok || payloadType != models.PAYLOAD_MERGE_PATCH {
+		t.Fatalf("modified: got %q, %v", payloadType, ok)
+	}
+	patch := payload.(models.MonitoringPatch)
+	want := map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": "2"},
+		"data":     map[string]interface{}{"a": "3", "b": nil},
+	}
+	if patch.UID != "uid-1" || patch.ResourceVersion != "2" || !reflect.DeepEqual(patch.Patch, want) {
+		t.Errorf("modified: got %+v, want patch %v", patch, want)
+	}
+
+	if _, payloadType, _ = tracker.Payload(watch.Deleted, testConfigMap("3", nil)); payloadType != models.PAYLOAD_OBJECT {
+		t.Errorf("deleted: got %q", payloadType)
+	}
+	if _, payloadType, _ = tracker.Payload(watch.Modified, testConfigMap("4", nil)); payloadType != models.PAYLOAD_OBJECT {
+		t.Errorf("modified after delete: got %q, want the full object", payloadType)
+	}
+}
+
+func TestDeltaTrackerSnapshot(t *testing.T) {
+	tests := []struct {
+		name       string
+		objects    int
+		deleted    int
+		chunkSize  int
+		wantChunks []int
+	}{
+		{name: "empty", objects: 0, chunkSize: 2, wantChunks: []int{0}},
+		{name: "single chunk", objects: 2, chunkSize: 2, wantChunks: []int{2}},
+		{name: "several chunks", objects: 5, chunkSize: 2, wantChunks: []int{2, 2, 1}},
+		{name: "deleted objects left out", objects: 5, deleted: 2, chunkSize: 2, wantChunks: []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := NewDeltaTracker()
+			for i := 0; i < tt.objects; i++ {
+				obj := testConfigMap("1", nil)
+				obj.UID = types.UID(fmt.Sprintf("uid-%d", i))
+				tracker.Payload(watch.Added, obj)
+			}
+			for i := 0; i < tt.deleted; i++ {
+				obj := testConfigMap("2", nil)
+				obj.UID = types.UID(fmt.Sprintf("uid-%d", i))
+				tracker.Payload(watch.Deleted, obj)
+			}
+
+			chunks := tracker.Snapshot(tt.chunkSize)
+			sizes := []int{}
+			for i, chunk := range chunks {
+				if chunk.ID != chunks[0].ID || chunk.Chunk != i || chunk.Chunks != len(chunks) {
+					t.Errorf("chunk %d = id %s, %d of %d", i, chunk.ID, chunk.Chunk, chunk.Chunks)
+				}
+				sizes = append(sizes, len(chunk.Items))
+			}
+			if fmt.Sprint(sizes) != fmt.Sprint(tt.wantChunks) {
+				t.Errorf("chunk sizes = %v, want %v", sizes, tt.wantChunks)
+			}
+		})
+	}
+}
+
+func TestDeltaTrackerSnapshotFollowsEvents(t *testing.T) {
+	tracker := NewDeltaTracker()
+	tracker.Payload(watch.Added, testConfigMap("1", map[string]string{"a": "1"}))
+	tracker.Payload(watch.Modified, testConfigMap("2", map[string]string{"a": "2"}))
+
+	// the snapshot is the last state sent, whatever the informer store
+	// already holds
+	items := tracker.Snapshot(10)[0].Items
+	if len(items) != 1 || !reflect.DeepEqual(items[0]["data"], map[string]interface{}{"a": "2"}) {
+		t.Fatalf("snapshot = %v", items)
+	}
+
+	payload, payloadType, _ := tracker.Payload(watch.Modified, testConfigMap("3", map[string]string{"a": "3"}))
+	if payloadType != models.PAYLOAD_MERGE_PATCH {
+		t.Fatalf("got %q", payloadType)
+	}
+	want := map[string]interface{}{"a": "3"}
+	if patch := payload.(models.MonitoringPatch).Patch; !reflect.DeepEqual(patch["data"], want) {
+		t.Errorf("patch = %v", patch)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type bufferedEvent struct {
+	seq  uint64
+	data []byte
+}
+
+type EventBufferStats struct {
+	Queued  int    `json:"queued"`
+	Sent    uint64 `json:"sent"`
+	Dropped uint64 `json:"dropped"`
+}
+
+// EventBuffer is a bounded FIFO queue between the watchers and the
+// monitoring session. When full the oldest event is dropped. With a
+// directory configured every queued event is also written to disk, so
+// events survive proxy outages as well as agent restarts.
+type EventBuffer struct {
+	Dir     string
+	Size    int
+	mu      sync.Mutex
+	cond    *sync.Cond
+	events  []bufferedEvent
+	nextSeq uint64
+	sent    uint64
+	dropped uint64
+}
+
+func NewEventBuffer(dir string, size int) (*EventBuffer, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("event buffer size must be positive, got %d", size)
+	}
+	b := &EventBuffer{
+		Dir:  dir,
+		Size: size,
+	}
+	b.cond = sync.NewCond(&b.mu)
+	if dir == "" {
+		return b, nil
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := b.load(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// load restores the events left on disk by a previous run, and removes
+// the events it was still writing when it crashed.
+func (b *EventBuffer) load() error {
+	files, err := ioutil.ReadDir(b.Dir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file.Name(), ".tmp") {
+			if err := os.Remove(filepath.Join(b.Dir, file.Name())); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(file.Name(), ".json"), 10, 64)
+		if err != nil || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(b.Dir, file.Name()))
+		if err != nil {
+			return err
+		}
+		b.events = append(b.events, bufferedEvent{seq: seq, data: data})
+	}
+	sort.Slice(b.events, func(i, j int) bool {
+		return b.events[i].seq < b.events[j].seq
+	})
+	if len(b.events) > 0 {
+		b.nextSeq = b.events[len(b.events)-1].seq + 1
+	}
+	for len(b.events) > b.Size {
+		b.dropOldest()
+	}
+	logrus.Infof("restored %d buffered events from %s", len(b.events), b.Dir)
+	return nil
+}
+
+// Push queues an event, dropping the oldest one when the buffer is full.
+func (b *EventBuffer) Push(data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	event := bufferedEvent{seq: b.nextSeq, data: data}
+	b.nextSeq++
+	if b.Dir != "" {
+		if err := b.persist(event); err != nil {
+			logrus.Errorf("persisting buffered event: %v", err)
+		}
+	}
+	if len(b.events) >= b.Size {
+		b.dropOldest()
+	}
+	b.events = append(b.events, event)
+	b.cond.Signal()
+}
+
+func (b *EventBuffer) dropOldest() {
+	b.remove(b.events[0])
+	b.events = b.events[1:]
+	b.dropped++
+	if b.dropped%1000 == 1 {
+		logrus.Warnf("event buffer full, %d events dropped so far", b.dropped)
+	}
+}
+
+func (b *EventBuffer) persist(event bufferedEvent) error {
+	path := b.path(event)
+	if err := ioutil.WriteFile(path+".tmp", event.data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (b *EventBuffer) remove(event bufferedEvent) {
+	if b.Dir == "" {
+		return
+	}
+	if err := os.Remove(b.path(event)); err != nil && !os.IsNotExist(err) {
+		logrus.Errorf("removing buffered event: %v", err)
+	}
+}
+
+func (b *EventBuffer) path(event bufferedEvent) string {
+	return filepath.Join(b.Dir, fmt.Sprintf("%020d.json", event.seq))
+}
+
+// peek blocks until an event is queued and returns the oldest one. It
+// returns false once the context is done.
+func (b *EventBuffer) peek(ctx context.Context) (bufferedEvent, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.events) == 0 && ctx.Err() == nil {
+		b.cond.Wait()
+	}
+	if ctx.Err() != nil {
+		return bufferedEvent{}, false
+	}
+	return b.events[0], true
+}
+
+// ack removes an event once it was sent, unless it was dropped meanwhile.
+func (b *EventBuffer) ack(event bufferedEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent++
+	if len(b.events) > 0 && b.events[0].seq == event.seq {
+		b.remove(event)
+		b.events = b.events[1:]
+	}
+}
+
+// Run sends the queued events in order over the session, keeping each one
+// until it was written successfully, until the context is done.
+func (b *EventBuffer) Run(ctx context.Context, session *utils.Session) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// wake up peek
+			b.mu.Lock()
+			b.cond.Broadcast()
+			b.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	backoff := utils.NewBackoff()
+	for {
+		event, ok := b.peek(ctx)
+		if !ok {
+			return
+		}
+		if err := session.SendContext(ctx, event.data); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			delay := backoff.Step()
+			logrus.Errorf("sending buffered event: %v, retrying in %s", err, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			continue
+		}
+		backoff = utils.NewBackoff()
+		b.ack(event)
+	}
+}
+
+// Drain sends the events left in the buffer once Run returned, giving up
+// when the context is done. Events it could not send stay on disk when the
+// buffer is persisted.
+func (b *EventBuffer) Drain(ctx context.Context, session *utils.Session) error {
+	for {
+		b.mu.Lock()
+		if len(b.events) == 0 {
+			b.mu.Unlock()
+			return nil
+		}
+		event := b.events[0]
+		b.mu.Unlock()
+
+		if err := session.SendContext(ctx, event.data); err != nil {
+			return fmt.Errorf("%d events left unsent: %v", b.Stats().Queued, err)
+		}
+		b.ack(event)
+	}
+}
+
+func (b *EventBuffer) Stats() EventBufferStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return EventBufferStats{
+		Queued:  len(b.events),
+		Sent:    b.sent,
+		Dropped: b.dropped,
+	}
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+)
+
+func queued(b *EventBuffer) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var result []string
+	for _, event := range b.events {
+		result = append(result, string(event.data))
+	}
+	return result
+}
+
+func pushAll(b *EventBuffer, events ...string) {
+	for _, event := range events {
+		b.Push([]byte(event))
+	}
+}
+
+func TestEventBufferEviction(t *testing.T) {
+	tests := []struct {
+		name        string
+		size        int
+		pushed      []string
+		wantQueued  []string
+		wantDropped uint64
+	}{
+		{"under the bound", 3, []string{"a", "b"}, []string{"a", "b"}, 0},
+		{"at the bound", 3, []string{"a", "b", "c"}, []string{"a", "b", "c"}, 0},
+		{"over the bound", 3, []string{"a", "b", "c", "d", "e"}, []string{"c", "d", "e"}, 2},
+		{"single slot", 1, []string{"a", "b"}, []string{"b"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, dir := range []string{"", t.TempDir()} {
+				b, err := NewEventBuffer(dir, tt.size)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pushAll(b, tt.pushed...)
+				if got := queued(b); fmt.Sprint(got) != fmt.Sprint(tt.wantQueued) {
+					t.Errorf("dir %q: queued %v, want %v", dir, got, tt.wantQueued)
+				}
+				if got := b.Stats().Dropped; got != tt.wantDropped {
+					t.Errorf("dir %q: dropped %d, want %d", dir, got, tt.wantDropped)
+				}
+			}
+		})
+	}
+}
+
+func TestNewEventBufferSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if _, err := NewEventBuffer("", size); err == nil {
+			t.Errorf("size %d: expected an error", size)
+		}
+	}
+}
+
+func TestEventBufferReload(t *testing.T) {
+	tests := []struct {
+		name       string
+		size       int
+		reloadSize int
+		pushed     []string
+		wantQueued []string
+	}{
+		{"keeps order", 20, 20, []string{"e0", "e1", "e2", "e3", "e4", "e5", "e6", "e7", "e8", "e9", "e10", "e11"}, []string{"e0", "e1", "e2", "e3", "e4", "e5", "e6", "e7", "e8", "e9", "e10", "e11"}},
+		{"evicted events are not restored", 2, 2, []string{"a", "b", "c"}, []string{"b", "c"}},
+		{"smaller bound drops the oldest", 4, 2, []string{"a", "b", "c", "d"}, []string{"c", "d"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			b, err := NewEventBuffer(dir, tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			pushAll(b, tt.pushed...)
+
+			reloaded, err := NewEventBuffer(dir, tt.reloadSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := queued(reloaded); fmt.Sprint(got) != fmt.Sprint(tt.wantQueued) {
+				t.Errorf("reloaded %v, want %v", got, tt.wantQueued)
+			}
+
+			// new events go after the restored ones, also across another restart
+			reloaded.Push([]byte("new"))
+			again, err := NewEventBuffer(dir, tt.reloadSize+1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := queued(reloaded)
+			if want[len(want)-1] != "new" {
+				t.Errorf("pushed event is not last: %v", want)
+			}
+			if got := queued(again); fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("after push %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEventBufferAckAfterFailedSend(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewEventBuffer(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushAll(b, "a", "b")
+
+	// nothing listens on the proxy address, so the send fails
+	unreachable := &utils.Session{Host: "127.0.0.1:1", Channel: "monitoring"}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := b.Drain(ctx, unreachable); err == nil {
+		t.Fatal("expected the drain to fail")
+	}
+	if got := queued(b); fmt.Sprint(got) != "[a b]" {
+		t.Fatalf("after failed send: queued %v, want [a b]", got)
+	}
+
+	// the event being retried is dropped while the send is in flight; its
+	// late ack must not remove the event now at the head
+	event, ok := b.peek(context.Background())
+	if !ok || string(event.data) != "a" {
+		t.Fatalf("peek = %q, %v", event.data, ok)
+	}
+	b.Push([]byte("c"))
+	b.ack(event)
+	if got := queued(b); fmt.Sprint(got) != "[b c]" {
+		t.Errorf("after late ack: queued %v, want [b c]", got)
+	}
+
+	head, _ := b.peek(context.Background())
+	b.ack(head)
+	if got := queued(b); fmt.Sprint(got) != "[c]" {
+		t.Errorf("after ack: queued %v, want [c]", got)
+	}
+	reloaded, err := NewEventBuffer(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := queued(reloaded); fmt.Sprint(got) != "[c]" {
+		t.Errorf("acked events left on disk: %v", got)
+	}
+}
+
+func TestEventBufferDrain(t *testing.T) {
+	received := make(chan string, 10)
+	upgrader := websocket.Upgrader{}
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			_, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			received <- string(message)
+		}
+	}))
+	defer proxy.Close()
+
+	b, err := NewEventBuffer("", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushAll(b, "a", "b", "c")
+	session := &utils.Session{Host: strings.TrimPrefix(proxy.URL, "http://"), Channel: "monitoring"}
+	defer session.Close()
+	if err := b.Drain(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"a", "b", "c"} {
+		select {
+		case got := <-received:
+			if got != want {
+				t.Errorf("received %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%q not received", want)
+		}
+	}
+	if stats := b.Stats(); stats.Queued != 0 || stats.Sent != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestEventBufferRemovesPartialWrites(t *testing.T) {
+	dir := t.TempDir()
+	b, err := NewEventBuffer(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushAll(b, "a", "b")
+	// a crash between the write and the rename of the next event
+	partial := filepath.Join(dir, fmt.Sprintf("%020d.json.tmp", 2))
+	if err := ioutil.WriteFile(partial, []byte("c"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewEventBuffer(dir, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := queued(reloaded); fmt.Sprint(got) != "[a b]" {
+		t.Errorf("reloaded %v, want [a b]", got)
+	}
+	if _, err := os.Stat(partial); !os.IsNotExist(err) {
+		t.Errorf("partial write left on disk: %v", err)
+	}
+}
@@ -7,11 +7,12 @@ import (
 )
 
 type MonitoringService struct {
-	NameSpace string
-	Resource  string
-	EventName string
-	PayLoad   interface{}
-	ClusterId string
+	NameSpace   string
+	Resource    string
+	EventName   string
+	PayLoad     interface{}
+	PayLoadType string
+	ClusterId   string
 }
 
 func (m MonitoringService) PushEvent(session *utils.Session) error {
@@ -24,3 +25,14 @@ func (m MonitoringService) PushEvent(session *utils.Session) error {
 
 	return nil
 }
+
+// BufferEvent queues the event for delivery on the monitoring session.
+func (m MonitoringService) BufferEvent(buffer *EventBuffer) error {
+	m.ClusterId = utils.NewConfig().ClientId
+	msg, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	buffer.Push(msg)
+	return nil
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kube-carbonara/cluster-agent/models"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+var portForwardsResource = schema.GroupResource{Resource: "portforwards"}
+
+var errPortForwardsClosed = apierrors.NewServiceUnavailable("the agent is shutting down, no tunnel can be opened")
+
+// PortForwards keeps the named tunnels opened to pods. Each one is a
+// client-go port-forward listening on an ephemeral loopback port, which the
+// REST API bridges to the remote proxy. A tunnel is dropped once its pod
+// connection is lost.
+type PortForwards struct {
+	mu       sync.Mutex
+	forwards map[string]*portForward
+	// closed is set by StopAll, after which no tunnel is opened
+	closed bool
+}
+
+type portForward struct {
+	info   models.PortForward
+	stopCh chan struct{}
+}
+
+func NewPortForwards() *PortForwards {
+	return &PortForwards{
+		forwards: map[string]*portForward{},
+	}
+}
+
+// Start opens a tunnel to forward.Port of forward.Pod with the given client
+// and returns it with its local port once it is listening.
+func (p *PortForwards) Start(client *utils.Client, forward models.PortForward) (models.PortForward, error) {
+	key := forward.NameSpace + "/" + forward.Name
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return forward, errPortForwardsClosed
+	}
+	if _, ok := p.forwards[key]; ok {
+		p.mu.Unlock()
+		return forward, apierrors.NewAlreadyExists(portForwardsResource, forward.Name)
+	}
+	// reserve the name while the tunnel is being opened
+	p.forwards[key] = nil
+	p.mu.Unlock()
+
+	started, err := p.start(client, key, forward)
+	if err != nil {
+		p.mu.Lock()
+		delete(p.forwards, key)
+		p.mu.Unlock()
+		return forward, err
+	}
+	return started, nil
+}
+
+func (p *PortForwards) start(client *utils.Client, key string, forward models.PortForward) (models.PortForward, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(client.Config)
+	if err != nil {
+		return forward, err
+	}
+	url := client.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(forward.NameSpace).
+		Name(forward.Pod).
+		SubResource("portforward").
+		URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	log := logrus.WithField("portforward", key)
+	errOut := log.WriterLevel(logrus.WarnLevel)
+	stopCh, readyCh := make(chan struct{}), make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", forward.Port)}, stopCh, readyCh, ioutil.Discard, errOut)
+	if err != nil {
+		errOut.Close()
+		return forward, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		errOut.Close()
+		if err == nil {
+			err = fmt.Errorf("port-forward to %s/%s closed before it was ready", forward.NameSpace, forward.Pod)
+		}
+		return forward, err
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		close(stopCh)
+		errOut.Close()
+		return forward, fmt.Errorf("port-forward to %s/%s has no local port: %v", forward.NameSpace, forward.Pod, err)
+	}
+	forward.LocalPort = int(ports[0].Local)
+	forward.CreatedAt = time.Now()
+
+	tunnel := &portForward{info: forward, stopCh: stopCh}
+	p.mu.Lock()
+	if p.closed {
+		// StopAll ran while the tunnel was being opened
+		p.mu.Unlock()
+		close(stopCh)
+		errOut.Close()
+		return forward, errPortForwardsClosed
+	}
+	p.forwards[key] = tunnel
+	p.mu.Unlock()
+
+	go func() {
+		err := <-errCh
+		errOut.Close()
+		p.mu.Lock()
+		if p.forwards[key] == tunnel {
+			delete(p.forwards, key)
+		}
+		p.mu.Unlock()
+		if err != nil {
+			log.Error("port-forward closed: ", err.Error())
+		} else {
+			log.Info("port-forward closed")
+		}
+	}()
+	return forward, nil
+}
+
+// Get returns the tunnel with the given name.
+func (p *PortForwards) Get(nameSpace string, name string) (models.PortForward, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tunnel := p.forwards[nameSpace+"/"+name]
+	if tunnel == nil {
+		return models.PortForward{}, apierrors.NewNotFound(portForwardsResource, name)
+	}
+	return tunnel.info, nil
+}
+
+// List returns the tunnels of a namespace, or of all namespaces when it is
+// empty, sorted by namespace and name.
+func (p *PortForwards) List(nameSpace string) []models.PortForward {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	items := []models.PortForward{}
+	for _, tunnel := range p.forwards {
+		if tunnel == nil || (nameSpace != "" && tunnel.info.NameSpace != nameSpace) {
+			continue
+		}
+		items = append(items, tunnel.info)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].NameSpace+"/"+items[i].Name < items[j].NameSpace+"/"+items[j].Name
+	})
+	return items
+}
+
+// Stop closes the tunnel with the given name.
+func (p *PortForwards) Stop(nameSpace string, name string) error {
+	key := nameSpace + "/" + name
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tunnel := p.forwards[key]
+	if tunnel == nil {
+		return apierrors.NewNotFound(portForwardsResource, name)
+	}
+	delete(p.forwards, key)
+	close(tunnel.stopCh)
+	return nil
+}
+
+// StopAll closes every tunnel and refuses new ones. The tunnels still
+// being opened are closed as soon as they are ready.
+func (p *PortForwards) StopAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for key, tunnel := range p.forwards {
+		if tunnel == nil {
+			continue
+		}
+		delete(p.forwards, key)
+		close(tunnel.stopCh)
+	}
+}
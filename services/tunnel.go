@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	utils "github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/rancher/remotedialer"
+	"github.com/sirupsen/logrus"
+)
+
+type TunnelStatus struct {
+	Connected      bool       `json:"connected"`
+	URL            string     `json:"url"`
+	LastConnect    *time.Time `json:"lastConnect,omitempty"`
+	LastDisconnect *time.Time `json:"lastDisconnect,omitempty"`
+	Reconnects     int        `json:"reconnects"`
+	LastError      string     `json:"lastError,omitempty"`
+	DeniedDials    uint64     `json:"deniedDials"`
+}
+
+// Tunnel keeps the remotedialer session to the proxy open. Lost or failed
+// connections are retried with exponential backoff and jitter until the
+// context is done, and the connection state is kept for status reporting.
+type Tunnel struct {
+	URL     string
+	Headers http.Header
+	Dialer  *websocket.Dialer
+	Policy  *utils.DialPolicy
+	// OnConnect and OnDisconnect, when set, are called as the session to
+	// the proxy goes up and down.
+	OnConnect    func()
+	OnDisconnect func(err error)
+
+	mu        sync.Mutex
+	status    TunnelStatus
+	connected int
+}
+
+// Run connects the tunnel and reconnects it until the context is done.
+func (t *Tunnel) Run(ctx context.Context) {
+	backoff := utils.NewBackoff()
+	for ctx.Err() == nil {
+		served, err := t.serve(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if served {
+			backoff = utils.NewBackoff()
+		}
+		if err == nil {
+			err = fmt.Errorf("tunnel closed by the proxy")
+		}
+		t.failed(err)
+
+		wait := backoff.Step()
+		logrus.WithError(err).WithField("url", t.URL).Warnf("tunnel down, reconnecting in %s", wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// serve connects to the proxy and serves dials until the session ends. It
+// tells whether the connection was established.
+func (t *Tunnel) serve(ctx context.Context) (bool, error) {
+	dialer := t.Dialer
+	if dialer == nil {
+		dialer = &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: remotedialer.HandshakeTimeOut,
+		}
+	}
+	logrus.WithField("url", t.URL).Info("connecting tunnel")
+	ws, resp, err := dialer.DialContext(ctx, t.URL, t.Headers)
+	if err != nil {
+		if resp != nil {
+			err = fmt.Errorf("%v: %s", err, resp.Status)
+		}
+		return false, err
+	}
+	defer ws.Close()
+
+	t.connect()
+	session := remotedialer.NewClientSession(t.Policy.Authorize, ws)
+	defer session.Close()
+	// Serve only returns once the socket fails
+	served := make(chan struct{})
+	defer close(served)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+			ws.Close()
+		case <-served:
+		}
+	}()
+	_, err = session.Serve(ctx)
+	t.disconnect(err)
+	return true, err
+}
+
+func (t *Tunnel) connect() {
+	now := time.Now()
+	t.mu.Lock()
+	t.status.Connected = true
+	t.status.LastConnect = &now
+	if t.connected > 0 {
+		t.status.Reconnects++
+	}
+	t.connected++
+	t.mu.Unlock()
+
+	logrus.WithField("url", t.URL).Info("tunnel connected")
+	if t.OnConnect != nil {
+		t.OnConnect()
+	}
+}
+
+func (t *Tunnel) disconnect(err error) {
+	now := time.Now()
+	t.mu.Lock()
+	t.status.Connected = false
+	t.status.LastDisconnect = &now
+	t.mu.Unlock()
+
+	if t.OnDisconnect != nil {
+		t.OnDisconnect(err)
+	}
+}
+
+func (t *Tunnel) failed(err error) {
+	t.mu.Lock()
+	t.status.LastError = err.Error()
+	t.mu.Unlock()
+}
+
+// Status returns the current connection state of the tunnel.
+func (t *Tunnel) Status() TunnelStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := t.status
+	status.URL = t.URL
+	if t.Policy != nil {
+		status.DeniedDials = t.Policy.Denied()
+	}
+	return status
+}
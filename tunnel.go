@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	routers "github.com/kube-carbonara/cluster-agent/routers"
+	"github.com/kube-carbonara/cluster-agent/services"
+	"github.com/kube-carbonara/cluster-agent/utils"
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// tunnel connects the remotedialer tunnel to the proxy, serving its status
+// and health probes on the local status address.
+func tunnel(ctx context.Context, config *utils.Config) error {
+	t := newTunnel(config)
+	if statusAddr != "" {
+		e := echo.New()
+		e.HideBanner = true
+		routers.TunnelRouter{Tunnel: t}.Handle(e)
+		routers.HealthRouter{Tunnel: t}.Handle(e)
+		go func() {
+			if err := e.Start(statusAddr); err != nil && err != http.ErrServerClosed {
+				logrus.Error("tunnel status server stopped: ", err)
+			}
+		}()
+		defer e.Close()
+	}
+	t.Run(ctx)
+	return nil
+}
+
+// all serves the REST API and tunnels it out from a single process. The
+// tunnel status is served by the REST API. The tunnel connects while the
+// server starts, and is only closed once the server shut down, as it
+// carries the requests being drained.
+func all(ctx context.Context, config *utils.Config) error {
+	t := newTunnel(config)
+	tunnelCtx, stopTunnel := context.WithCancel(context.Background())
+	tunnelDone := make(chan struct{})
+	go func() {
+		defer close(tunnelDone)
+		t.Run(tunnelCtx)
+	}()
+
+	err := newServer(ctx, config, t).run(ctx)
+	stopTunnel()
+	<-tunnelDone
+	return err
+}
+
+func newTunnel(config *utils.Config) *services.Tunnel {
+	policy, err := utils.DialPolicyFromConfig(config)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	dialer, err := utils.NewProxyDialer(config)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	return &services.Tunnel{
+		URL: addr,
+		Headers: http.Header{
+			"X-Tunnel-ID":     []string{id},
+			"x-agent":         []string{id},
+			"x-agent-app-key": []string{appKey},
+		},
+		Dialer: dialer,
+		Policy: policy,
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"math"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// NewBackoff returns an exponential backoff with jitter, starting at one
+// second and capped at one minute, that never runs out of steps.
+func NewBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.5,
+		Steps:    math.MaxInt32,
+		Cap:      time.Minute,
+	}
+}
@@ -2,39 +2,144 @@ package utils
 
 import (
 	"fmt"
+	"strings"
+	"sync"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	networkingv1client "k8s.io/client-go/kubernetes/typed/networking/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 	metricsv1alpha1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1alpha1"
 	metricsv1beta1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 )
 
+// Client holds the clientsets shared by the whole process. It is created
+// once at startup and injected into the routers, controllers and services.
 type Client struct {
+	Config             *rest.Config
 	Clientset          *kubernetes.Clientset
 	Networkingv1client *networkingv1client.NetworkingV1Client
 	MetricsV1alpha1    *metricsv1alpha1.MetricsV1alpha1Client
 	MetricsV1beta1     *metricsv1beta1.MetricsV1beta1Client
+	Dynamic            dynamic.Interface
+	// Mapper resolves resources from the discovery of the agent. It is
+	// shared with the impersonated clients.
+	Mapper *RESTMapper
+
+	mu           sync.Mutex
+	impersonated map[string]*Client
+}
+
+const maxImpersonatedClients = 256
+
+// ClientOptions selects the kubeconfig used when the agent runs outside of
+// a cluster and the rate limits applied to every call to the API server.
+type ClientOptions struct {
+	KubeConfig  string
+	KubeContext string
+	QPS         float32
+	Burst       int
+	UserAgent   string
 }
 
-func NewClient() *Client {
-	config, err := rest.InClusterConfig()
+func NewClient(options ClientOptions) (*Client, error) {
+	config, err := RestConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	config.QPS = options.QPS
+	config.Burst = options.Burst
+	config.UserAgent = options.UserAgent
+	client, err := newClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	client.Mapper = NewRESTMapper(client.Clientset.Discovery())
+	return client, nil
+}
+
+func newClientForConfig(config *rest.Config) (*Client, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	ntClient, err := networkingv1client.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mtClientBeta, err := metricsv1beta1.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mtClientAlpha, err := metricsv1alpha1.NewForConfig(config)
 	if err != nil {
-		fmt.Print(err.Error())
-		panic(err.Error())
+		return nil, err
 	}
-	clientset, _ := kubernetes.NewForConfig(config)
-	ntClient, _ := networkingv1client.NewForConfig(config)
-	mtClientBeta, _ := metricsv1beta1.NewForConfig(config)
-	mtClientAlpha, _ := metricsv1alpha1.NewForConfig(config)
+	dynamicClient, err := dynamic.NewForConfig(config)
 	if err != nil {
-		fmt.Print(err.Error())
-		panic(err.Error())
+		return nil, err
 	}
 	return &Client{
+		Config:             config,
 		Clientset:          clientset,
 		Networkingv1client: ntClient,
 		MetricsV1alpha1:    mtClientAlpha,
 		MetricsV1beta1:     mtClientBeta,
+		Dynamic:            dynamicClient,
+	}, nil
+}
+
+// Impersonate returns a client acting as the given user, so that cluster
+// RBAC and audit logs apply to the end user rather than to the agent.
+// Clients are cached per user and groups.
+func (c *Client) Impersonate(user string, groups []string) (*Client, error) {
+	key := user + "\x00" + strings.Join(groups, ",")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if client, ok := c.impersonated[key]; ok {
+		return client, nil
+	}
+
+	config := rest.CopyConfig(c.Config)
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: user,
+		Groups:   groups,
+	}
+	client, err := newClientForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	client.Mapper = c.Mapper
+	if c.impersonated == nil || len(c.impersonated) >= maxImpersonatedClients {
+		c.impersonated = map[string]*Client{}
+	}
+	c.impersonated[key] = client
+	return client, nil
+}
+
+// RestConfig resolves the cluster config. An explicit kubeconfig wins,
+// otherwise the in-cluster service account is used and, when not running
+// in a pod, the default loading rules (KUBECONFIG env, ~/.kube/config).
+func RestConfig(options ClientOptions) (*rest.Config, error) {
+	if options.KubeConfig == "" {
+		config, err := rest.InClusterConfig()
+		if err == nil {
+			return config, nil
+		}
+		if err != rest.ErrNotInCluster {
+			return nil, err
+		}
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = options.KubeConfig
+	overrides := &clientcmd.ConfigOverrides{
+		CurrentContext: options.KubeContext,
+	}
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load in-cluster or kubeconfig configuration: %v", err)
 	}
+	return config, nil
 }
@@ -1,19 +1,145 @@
 package utils
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Config struct {
-	RemoteProxy  string
-	RemoteSchema string
-	ClientId     string
-	AppKey       string
+	RemoteProxy   string
+	RemoteSchema  string
+	ClientId      string
+	AppKey        string
+	KubeContext   string
+	KubeQPS       float32
+	KubeBurst     int
+	KubeUserAgent string
+
+	EventBufferDir  string
+	EventBufferSize int
+
+	MonitoringDelta        bool
+	MonitoringResyncPeriod time.Duration
+	MonitoringResources    []string
+
+	SecretRevealKey string
+
+	// AuthTokenSecret signs the bearer tokens, the app key is used when
+	// it is empty
+	AuthTokenSecret   string
+	AuthTokenAudience string
+	AuthAppKeyRole    string
+	RbacPolicyFile    string
+
+	RequireImpersonation bool
+
+	TunnelAllowCIDRs []string
+	TunnelAllowHosts []string
+	TunnelAllowPorts []string
+	TunnelStatusAddr string
+
+	ProxyCAFile         string
+	ProxyClientCertFile string
+	ProxyClientKeyFile  string
+	ProxyServerName     string
+
+	ShutdownDrainTimeout time.Duration
 }
 
 func NewConfig() *Config {
 	return &Config{
-		RemoteProxy:  os.Getenv("SERVER_ADDRESS"),
-		ClientId:     os.Getenv("CLIENT_ID"),
-		AppKey:       os.Getenv("APP_KEY"),
-		RemoteSchema: os.Getenv("REMOTE_SCHEMA"),
+		RemoteProxy:   os.Getenv("SERVER_ADDRESS"),
+		ClientId:      os.Getenv("CLIENT_ID"),
+		AppKey:        os.Getenv("APP_KEY"),
+		RemoteSchema:  getEnv("REMOTE_SCHEMA", "https"),
+		KubeContext:   os.Getenv("KUBE_CONTEXT"),
+		KubeQPS:       float32(getEnvFloat("KUBE_API_QPS", 50)),
+		KubeBurst:     getEnvInt("KUBE_API_BURST", 100),
+		KubeUserAgent: getEnv("KUBE_USER_AGENT", "kube-carbonara-cluster-agent"),
+
+		EventBufferDir:  os.Getenv("EVENT_BUFFER_DIR"),
+		EventBufferSize: getEnvInt("EVENT_BUFFER_SIZE", 10000),
+
+		MonitoringDelta:        getEnvBool("MONITORING_DELTA", false),
+		MonitoringResyncPeriod: getEnvDuration("MONITORING_RESYNC_PERIOD", 10*time.Minute),
+		MonitoringResources:    getEnvList("MONITORING_RESOURCES", nil),
+
+		SecretRevealKey: os.Getenv("SECRET_REVEAL_KEY"),
+
+		AuthTokenSecret:   os.Getenv("AUTH_TOKEN_SECRET"),
+		AuthTokenAudience: os.Getenv("AUTH_TOKEN_AUDIENCE"),
+		AuthAppKeyRole:    getEnv("AUTH_APP_KEY_ROLE", "admin"),
+		RbacPolicyFile:    os.Getenv("RBAC_POLICY_FILE"),
+
+		RequireImpersonation: getEnvBool("REQUIRE_IMPERSONATION", false),
+
+		TunnelAllowCIDRs: getEnvList("TUNNEL_ALLOW_CIDRS", []string{"127.0.0.1/32", "::1/128"}),
+		TunnelAllowHosts: getEnvList("TUNNEL_ALLOW_HOSTS", []string{"localhost"}),
+		TunnelAllowPorts: getEnvList("TUNNEL_ALLOW_PORTS", []string{"1323"}),
+		TunnelStatusAddr: getEnv("TUNNEL_STATUS_ADDRESS", "127.0.0.1:1324"),
+
+		ProxyCAFile:         os.Getenv("PROXY_CA_FILE"),
+		ProxyClientCertFile: os.Getenv("PROXY_CLIENT_CERT_FILE"),
+		ProxyClientKeyFile:  os.Getenv("PROXY_CLIENT_KEY_FILE"),
+		ProxyServerName:     os.Getenv("PROXY_SERVER_NAME"),
+
+		ShutdownDrainTimeout: getEnvDuration("SHUTDOWN_DRAIN_TIMEOUT", 10*time.Second),
+	}
+}
+
+func getEnv(key string, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 32)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvList reads a comma separated list. An unset variable gives the
+// fallback, while an empty one gives an empty list.
+func getEnvList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
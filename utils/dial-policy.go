@@ -0,0 +1,142 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DialPolicy is the allow-list of the destinations the remote proxy may
+// dial through the tunnel. A destination is allowed when its port is
+// allowed and its host is either an IP inside an allowed CIDR or a name
+// matching an allowed host pattern (path.Match syntax, like
+// *.svc.cluster.local). Names are never resolved, so a name can only be
+// allowed by a pattern. Only TCP is dialed.
+type DialPolicy struct {
+	cidrs  []*net.IPNet
+	hosts  []string
+	ports  []portRange
+	denied uint64
+}
+
+type portRange struct {
+	from, to int
+}
+
+// NewDialPolicy parses the allow-list. Ports are single ports, ranges like
+// 8000-8100 or * for any port.
+func NewDialPolicy(cidrs []string, hosts []string, ports []string) (*DialPolicy, error) {
+	policy := &DialPolicy{}
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tunnel CIDR %q: %v", cidr, err)
+		}
+		policy.cidrs = append(policy.cidrs, network)
+	}
+	for _, host := range hosts {
+		if _, err := path.Match(host, ""); err != nil {
+			return nil, fmt.Errorf("invalid tunnel host pattern %q: %v", host, err)
+		}
+		policy.hosts = append(policy.hosts, strings.ToLower(host))
+	}
+	for _, port := range ports {
+		allowed, err := parsePortRange(port)
+		if err != nil {
+			return nil, err
+		}
+		policy.ports = append(policy.ports, allowed)
+	}
+	return policy, nil
+}
+
+// DialPolicyFromConfig builds the policy from the TUNNEL_ALLOW_* settings,
+// which by default only allow the local REST API.
+func DialPolicyFromConfig(config *Config) (*DialPolicy, error) {
+	return NewDialPolicy(config.TunnelAllowCIDRs, config.TunnelAllowHosts, config.TunnelAllowPorts)
+}
+
+// Authorize has the signature of remotedialer.ConnectAuthorizer. Denied
+// dials are logged and counted.
+func (p *DialPolicy) Authorize(proto string, address string) bool {
+	if p.Allows(proto, address) {
+		return true
+	}
+	denied := atomic.AddUint64(&p.denied, 1)
+	logrus.WithFields(logrus.Fields{
+		"proto":   proto,
+		"address": address,
+		"denied":  denied,
+	}).Warn("tunnel dial denied by policy")
+	return false
+}
+
+// Allows tells whether the policy allows dialing the address.
+func (p *DialPolicy) Allows(proto string, address string) bool {
+	switch proto {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return false
+	}
+	host, portValue, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	port, err := strconv.Atoi(portValue)
+	if err != nil || !p.allowsPort(port) {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, network := range p.cidrs {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range p.hosts {
+		if matched, _ := path.Match(pattern, host); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Denied returns how many dials were denied since the agent started.
+func (p *DialPolicy) Denied() uint64 {
+	return atomic.LoadUint64(&p.denied)
+}
+
+func (p *DialPolicy) allowsPort(port int) bool {
+	for _, allowed := range p.ports {
+		if port >= allowed.from && port <= allowed.to {
+			return true
+		}
+	}
+	return false
+}
+
+func parsePortRange(value string) (portRange, error) {
+	if value == "*" {
+		return portRange{from: 1, to: 65535}, nil
+	}
+	from, to := value, value
+	if i := strings.Index(value, "-"); i > 0 {
+		from, to = value[:i], value[i+1:]
+	}
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return portRange{}, fmt.Errorf("invalid tunnel port %q", value)
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil || start < 1 || end > 65535 || start > end {
+		return portRange{}, fmt.Errorf("invalid tunnel port %q", value)
+	}
+	return portRange{from: start, to: end}, nil
+}
@@ -0,0 +1,106 @@
+package utils
+
+import "testing"
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    portRange
+		wantErr bool
+	}{
+		{value: "*", want: portRange{from: 1, to: 65535}},
+		{value: "80", want: portRange{from: 80, to: 80}},
+		{value: "8000-8100", want: portRange{from: 8000, to: 8100}},
+		{value: "1-65535", want: portRange{from: 1, to: 65535}},
+		{value: "443-443", want: portRange{from: 443, to: 443}},
+		{value: "", wantErr: true},
+		{value: "http", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "65536", wantErr: true},
+		{value: "-80", wantErr: true},
+		{value: "80-", wantErr: true},
+		{value: "8100-8000", wantErr: true},
+		{value: "1-70000", wantErr: true},
+		{value: "80-90-100", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parsePortRange(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePortRange(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parsePortRange(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDialPolicyErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cidrs []string
+		hosts []string
+		ports []string
+	}{
+		{name: "cidr", cidrs: []string{"10.0.0.0"}},
+		{name: "host pattern", hosts: []string{"[a-"}},
+		{name: "port", ports: []string{"http"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDialPolicy(tt.cidrs, tt.hosts, tt.ports); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDialPolicyAllows(t *testing.T) {
+	policy, err := NewDialPolicy(
+		[]string{"127.0.0.0/8", "10.96.0.0/12", "fd00::/8"},
+		[]string{"*.svc.cluster.local", "Registry.example.com"},
+		[]string{"80", "443", "8000-8100"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		proto   string
+		address string
+		want    bool
+	}{
+		{"tcp", "127.0.0.1:80", true},
+		{"tcp4", "10.100.1.2:8050", true},
+		{"tcp6", "[fd00::1]:443", true},
+		{"tcp", "[::ffff:127.0.0.1]:80", true},
+		{"tcp", "127.0.0.1:22", false},
+		{"tcp", "127.0.0.1:8101", false},
+		{"tcp", "192.168.1.1:80", false},
+		{"tcp", "[fe80::1]:80", false},
+		{"udp", "127.0.0.1:80", false},
+		{"unix", "/var/run/docker.sock", false},
+		{"tcp", "web.default.svc.cluster.local:80", true},
+		{"tcp", "WEB.default.svc.cluster.local.:443", true},
+		{"tcp", "registry.example.com:443", true},
+		{"tcp", "svc.cluster.local:80", false},
+		{"tcp", "web.default.svc.cluster.local.evil.com:80", false},
+		{"tcp", "web.default.svc.cluster.local:22", false},
+		{"tcp", "localhost:80", false},
+		{"tcp", "127.0.0.1", false},
+		{"tcp", "127.0.0.1:http", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proto+" "+tt.address, func(t *testing.T) {
+			if got := policy.Allows(tt.proto, tt.address); got != tt.want {
+				t.Errorf("Allows(%q, %q) = %v, want %v", tt.proto, tt.address, got, tt.want)
+			}
+		})
+	}
+
+	empty, _ := NewDialPolicy(nil, nil, nil)
+	if empty.Allows("tcp", "127.0.0.1:80") {
+		t.Errorf("an empty policy should deny everything")
+	}
+}
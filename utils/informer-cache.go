@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// InformerCache holds the shared informers feeding both the monitoring
+// watchers and the REST read endpoints from a local indexed cache.
+type InformerCache struct {
+	Factory   informers.SharedInformerFactory
+	informers map[string]cache.SharedIndexInformer
+	// errors is the last list or watch error of each informer
+	errors   map[string]string
+	errorsMu sync.Mutex
+}
+
+func NewInformerCache(client *Client, resync time.Duration) *InformerCache {
+	factory := informers.NewSharedInformerFactory(client.Clientset, resync)
+	c := &InformerCache{
+		Factory: factory,
+		errors:  map[string]string{},
+	}
+
+	// informers have to be requested before the factory is started,
+	// otherwise they are never run.
+	c.informers = map[string]cache.SharedIndexInformer{
+		"pods":                          factory.Core().V1().Pods().Informer(),
+		"services":                      factory.Core().V1().Services().Informer(),
+		"nodes":                         factory.Core().V1().Nodes().Informer(),
+		"namespaces":                    factory.Core().V1().Namespaces().Informer(),
+		"secrets":                       factory.Core().V1().Secrets().Informer(),
+		"configmaps":                    factory.Core().V1().ConfigMaps().Informer(),
+		"persistentvolumeclaims":        factory.Core().V1().PersistentVolumeClaims().Informer(),
+		"persistentvolumes":             factory.Core().V1().PersistentVolumes().Informer(),
+		"storageclasses.storage.k8s.io": factory.Storage().V1().StorageClasses().Informer(),
+		"events":                        factory.Core().V1().Events().Informer(),
+		"deployments.apps":              factory.Apps().V1().Deployments().Informer(),
+		"statefulsets.apps":             factory.Apps().V1().StatefulSets().Informer(),
+		"daemonsets.apps":               factory.Apps().V1().DaemonSets().Informer(),
+		"replicasets.apps":              factory.Apps().V1().ReplicaSets().Informer(),
+		"jobs.batch":                    factory.Batch().V1().Jobs().Informer(),
+		"cronjobs.batch":                factory.Batch().V1().CronJobs().Informer(),
+		"ingresses.networking.k8s.io":   factory.Networking().V1().Ingresses().Informer(),
+	}
+	for name, informer := range c.informers {
+		name := name
+		informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			c.setError(name, err)
+		})
+	}
+	return c
+}
+
+// Start runs the informers without waiting for their caches to sync, so
+// that the agent answers its probes meanwhile. An informer which can not
+// list its resource, e.g. for lack of RBAC, keeps retrying: its error is
+// logged and reported by Unsynced.
+func (c *InformerCache) Start(stopCh <-chan struct{}) {
+	c.Factory.Start(stopCh)
+	logrus.Info("waiting for informer caches to sync")
+	go func() {
+		synced := make([]cache.InformerSynced, 0, len(c.informers))
+		for _, informer := range c.informers {
+			synced = append(synced, informer.HasSynced)
+		}
+		if cache.WaitForCacheSync(stopCh, synced...) {
+			logrus.Info("informer caches synced")
+		}
+	}()
+}
+
+func (c *InformerCache) HasSynced() bool {
+	for _, informer := range c.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Unsynced returns the informers whose cache is not synced yet, sorted,
+// with their last error if any.
+func (c *InformerCache) Unsynced() []InformerState {
+	c.errorsMu.Lock()
+	defer c.errorsMu.Unlock()
+	var unsynced []InformerState
+	for name, informer := range c.informers {
+		if !informer.HasSynced() {
+			unsynced = append(unsynced, InformerState{Resource: name, LastError: c.errors[name]})
+		}
+	}
+	sort.Slice(unsynced, func(i, j int) bool {
+		return unsynced[i].Resource < unsynced[j].Resource
+	})
+	return unsynced
+}
+
+// InformerState is the sync state of the informer of a resource.
+type InformerState struct {
+	Resource  string `json:"resource"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// setError records a failed list or watch, logging it when it changed
+// since the reflector retries every second or so.
+func (c *InformerCache) setError(name string, err error) {
+	c.errorsMu.Lock()
+	defer c.errorsMu.Unlock()
+	if c.errors[name] == err.Error() {
+		return
+	}
+	c.errors[name] = err.Error()
+	logrus.WithField("resource", name).Warnf("informer failed to list or watch: %v", err)
+}
@@ -0,0 +1,33 @@
+package utils
+
+import "reflect"
+
+// CreateMergePatch returns the JSON merge patch (RFC 7386) turning original
+// into modified. Both documents are JSON objects decoded into maps; removed
+// keys are set to nil and arrays are replaced as a whole.
+func CreateMergePatch(original map[string]interface{}, modified map[string]interface{}) map[string]interface{} {
+	patch := map[string]interface{}{}
+	for key, modifiedValue := range modified {
+		originalValue, ok := original[key]
+		if !ok {
+			patch[key] = modifiedValue
+			continue
+		}
+		if reflect.DeepEqual(originalValue, modifiedValue) {
+			continue
+		}
+		originalMap, originalIsMap := originalValue.(map[string]interface{})
+		modifiedMap, modifiedIsMap := modifiedValue.(map[string]interface{})
+		if originalIsMap && modifiedIsMap {
+			patch[key] = CreateMergePatch(originalMap, modifiedMap)
+			continue
+		}
+		patch[key] = modifiedValue
+	}
+	for key := range original {
+		if _, ok := modified[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	return patch
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCreateMergePatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		modified string
+		want     string
+	}{
+		{
+			name:     "no change",
+			original: `{"a":1,"b":{"c":"d"}}`,
+			modified: `{"a":1,"b":{"c":"d"}}`,
+			want:     `{}`,
+		},
+		{
+			name:     "added key",
+			original: `{"a":1}`,
+			modified: `{"a":1,"b":2}`,
+			want:     `{"b":2}`,
+		},
+		{
+			name:     "changed key",
+			original: `{"a":1,"b":2}`,
+			modified: `{"a":1,"b":3}`,
+			want:     `{"b":3}`,
+		},
+		{
+			name:     "removed key is null",
+			original: `{"a":1,"b":2}`,
+			modified: `{"a":1}`,
+			want:     `{"b":null}`,
+		},
+		{
+			name:     "nested map",
+			original: `{"metadata":{"labels":{"app":"web","tier":"front"},"name":"x"}}`,
+			modified: `{"metadata":{"labels":{"app":"api"},"name":"x"}}`,
+			want:     `{"metadata":{"labels":{"app":"api","tier":null}}}`,
+		},
+		{
+			name:     "nested map removed",
+			original: `{"spec":{"selector":{"app":"web"}}}`,
+			modified: `{"spec":{}}`,
+			want:     `{"spec":{"selector":null}}`,
+		},
+		{
+			name:     "map replaced by scalar",
+			original: `{"a":{"b":1}}`,
+			modified: `{"a":"b"}`,
+			want:     `{"a":"b"}`,
+		},
+		{
+			name:     "arrays replaced whole",
+			original: `{"items":[1,2,3]}`,
+			modified: `{"items":[1,3]}`,
+			want:     `{"items":[1,3]}`,
+		},
+		{
+			name:     "null value kept",
+			original: `{"a":1}`,
+			modified: `{"a":null}`,
+			want:     `{"a":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CreateMergePatch(decode(t, tt.original), decode(t, tt.modified))
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("CreateMergePatch() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, document string) map[string]interface{} {
+	t.Helper()
+	result := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(document), &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
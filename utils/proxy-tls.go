@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	proxyHandshakeTimeout = 10 * time.Second
+	proxyRequestTimeout   = 30 * time.Second
+)
+
+// ProxySecure tells whether the proxy is reached over TLS, which is the
+// case when REMOTE_SCHEMA is https. Websockets then use wss.
+func ProxySecure(config *Config) bool {
+	return config.RemoteSchema == "https"
+}
+
+// WarnInsecureProxy logs a warning when the proxy is reached without TLS
+// anywhere but on the local host, as the app key and the cluster state
+// would then cross the network in clear.
+func WarnInsecureProxy(config *Config) {
+	if ProxySecure(config) {
+		return
+	}
+	host := config.RemoteProxy
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return
+	}
+	logrus.Warnf("connecting to the proxy %s without TLS, set REMOTE_SCHEMA=https", config.RemoteProxy)
+}
+
+// ProxyWebsocketScheme returns the scheme of the websockets to the proxy.
+func ProxyWebsocketScheme(config *Config) string {
+	if ProxySecure(config) {
+		return "wss"
+	}
+	return "ws"
+}
+
+// ProxyTLSConfig returns the TLS settings of every connection to the proxy.
+// The server certificate is verified against PROXY_CA_FILE when set (the
+// system roots otherwise) and for PROXY_SERVER_NAME when set (the proxy
+// host otherwise). PROXY_CLIENT_CERT_FILE and PROXY_CLIENT_KEY_FILE enable
+// mutual TLS.
+func ProxyTLSConfig(config *Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: config.ProxyServerName,
+	}
+	if config.ProxyCAFile != "" {
+		pem, err := ioutil.ReadFile(config.ProxyCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the proxy CA bundle: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in the proxy CA bundle %s", config.ProxyCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.ProxyClientCertFile != "" || config.ProxyClientKeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(config.ProxyClientCertFile, config.ProxyClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load the proxy client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+	return tlsConfig, nil
+}
+
+// NewProxyDialer returns the websocket dialer of the monitoring session and
+// the tunnel.
+func NewProxyDialer(config *Config) (*websocket.Dialer, error) {
+	tlsConfig, err := ProxyTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: proxyHandshakeTimeout,
+		TLSClientConfig:  tlsConfig,
+	}, nil
+}
+
+// NewProxyHTTPClient returns the client of the REST calls to the proxy.
+func NewProxyHTTPClient(config *Config) (*http.Client, error) {
+	tlsConfig, err := ProxyTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{
+		Transport: transport,
+		Timeout:   proxyRequestTimeout,
+	}, nil
+}
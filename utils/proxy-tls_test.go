@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProxyWebsocketScheme(t *testing.T) {
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{schema: "https", want: "wss"},
+		{schema: "http", want: "ws"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			if got := ProxyWebsocketScheme(&Config{RemoteSchema: tt.schema}); got != tt.want {
+				t.Errorf("ProxyWebsocketScheme() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewConfigDefaultsToTLS(t *testing.T) {
+	if value, ok := os.LookupEnv("REMOTE_SCHEMA"); ok {
+		defer os.Setenv("REMOTE_SCHEMA", value)
+	}
+	os.Unsetenv("REMOTE_SCHEMA")
+	if config := NewConfig(); !ProxySecure(config) {
+		t.Errorf("REMOTE_SCHEMA defaults to %q, want https", config.RemoteSchema)
+	}
+}
@@ -1,14 +1,26 @@
 package utils
 
 const (
-	RESOUCETYPE_NODES       string = "Nodes"
-	RESOUCETYPE_NAMESPACES  string = "Name Spaces"
-	RESOUCETYPE_PODS        string = "Pods"
-	RESOUCETYPE_DEPLOYMENTS string = "Deployments"
-	RESOUCETYPE_SERVICES    string = "Services"
-	RESOUCETYPE_INGRESS     string = "Ingress"
-	RESOUCETYPE_SECRETS     string = "Secrets"
-	EVENTS                  string = "Events"
-	WORK_LOAD               string = "Work Load"
-	APPS                    string = "Apps"
+	RESOUCETYPE_NODES                  string = "Nodes"
+	RESOUCETYPE_NAMESPACES             string = "Name Spaces"
+	RESOUCETYPE_PODS                   string = "Pods"
+	RESOUCETYPE_DEPLOYMENTS            string = "Deployments"
+	RESOUCETYPE_STATEFULSETS           string = "Stateful Sets"
+	RESOUCETYPE_DAEMONSETS             string = "Daemon Sets"
+	RESOUCETYPE_REPLICASETS            string = "Replica Sets"
+	RESOUCETYPE_JOBS                   string = "Jobs"
+	RESOUCETYPE_CRONJOBS               string = "Cron Jobs"
+	RESOUCETYPE_SERVICES               string = "Services"
+	RESOUCETYPE_INGRESS                string = "Ingress"
+	RESOUCETYPE_SECRETS                string = "Secrets"
+	RESOUCETYPE_CONFIGMAPS             string = "Config Maps"
+	RESOUCETYPE_PERSISTENTVOLUMECLAIMS string = "Persistent Volume Claims"
+	RESOUCETYPE_PERSISTENTVOLUMES      string = "Persistent Volumes"
+	RESOUCETYPE_STORAGECLASSES         string = "Storage Classes"
+	EVENTS                             string = "Events"
+	WORK_LOAD                          string = "Work Load"
+	APPS                               string = "Apps"
+	RESOUCETYPE_PORTFORWARDS           string = "Port Forwards"
+	RESOUCETYPE_TUNNEL                 string = "Tunnel"
+	RESOUCETYPE_HEALTH                 string = "Health"
 )
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	memory "k8s.io/client-go/discovery/cached"
+	"k8s.io/client-go/restmapper"
+)
+
+// CORE_GROUP names the legacy API group, which has no name, in routes and
+// settings.
+const CORE_GROUP string = "core"
+
+// GroupVersionResource builds the resource named by a route or a setting,
+// where the core group is spelled CORE_GROUP.
+func GroupVersionResource(group string, version string, resource string) schema.GroupVersionResource {
+	if group == CORE_GROUP {
+		group = ""
+	}
+	return schema.GroupVersionResource{Group: group, Version: version, Resource: resource}
+}
+
+// ParseGroupVersionResource parses a group/version/resource setting, like
+// cert-manager.io/v1/certificates or core/v1/configmaps.
+func ParseGroupVersionResource(value string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return schema.GroupVersionResource{}, fmt.Errorf("invalid resource %q, expected group/version/resource", value)
+	}
+	return GroupVersionResource(parts[0], parts[1], parts[2]), nil
+}
+
+// mapperRefreshInterval bounds how often discovery is refreshed for
+// resources missing from the cache, which callers could otherwise trigger
+// on every request with made up resources.
+const mapperRefreshInterval = 30 * time.Second
+
+// RESTMapper resolves resources with the cached discovery of the agent.
+type RESTMapper struct {
+	*restmapper.DeferredDiscoveryRESTMapper
+
+	mu          sync.Mutex
+	refreshedAt time.Time
+}
+
+func NewRESTMapper(client discovery.DiscoveryInterface) *RESTMapper {
+	return &RESTMapper{
+		DeferredDiscoveryRESTMapper: restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(client)),
+	}
+}
+
+// refresh drops the cached discovery, unless it was dropped less than
+// mapperRefreshInterval ago. It tells whether it did.
+func (m *RESTMapper) refresh() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.refreshedAt.IsZero() && time.Since(m.refreshedAt) < mapperRefreshInterval {
+		return false
+	}
+	m.refreshedAt = time.Now()
+	m.Reset()
+	return true
+}
+
+// RESTMapping resolves a resource to its kind, canonical name and scope.
+// Discovery is cached, so a resource missing from the cache is looked up
+// once more, at most every mapperRefreshInterval, to find the CRDs
+// installed since.
+func (c *Client) RESTMapping(gvr schema.GroupVersionResource) (*meta.RESTMapping, error) {
+	gvk, err := c.Mapper.KindFor(gvr)
+	if meta.IsNoMatchError(err) && c.Mapper.refresh() {
+		gvk, err = c.Mapper.KindFor(gvr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
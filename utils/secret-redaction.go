@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"crypto/subtle"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// RedactSecret returns a copy of the secret holding metadata only. The
+// values are dropped, along with the last applied configuration which
+// would carry them in clear.
+func RedactSecret(secret *v1.Secret) *v1.Secret {
+	redacted := secret.DeepCopy()
+	redacted.Data = nil
+	redacted.StringData = nil
+	if _, ok := redacted.Annotations[lastAppliedConfigAnnotation]; ok {
+		delete(redacted.Annotations, lastAppliedConfigAnnotation)
+	}
+	return redacted
+}
+
+// CanRevealSecrets tells whether a request asking for secret values
+// presented the reveal key configured on the agent. Without a configured
+// key secrets are never revealed.
+func CanRevealSecrets(revealKey string) bool {
+	configured := NewConfig().SecretRevealKey
+	return configured != "" && subtle.ConstantTimeCompare([]byte(revealKey), []byte(configured)) == 1
+}
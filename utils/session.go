@@ -1,36 +1,158 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/url"
-	"os"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
 )
 
+const sessionWriteTimeout = 10 * time.Second
+
+// Session is a websocket to a channel of the remote proxy. A single session
+// is shared by all the resource streams; messages are told apart by their
+// resource type. A lost connection is re-dialed with exponential backoff
+// and jitter on the next Send, so a proxy outage never stops the agent.
+// Scheme defaults to ws and Dialer to the default websocket dialer.
 type Session struct {
 	Host    string
 	Channel string
+	Scheme  string
+	Dialer  *websocket.Dialer
 	Conn    *websocket.Conn
 	mu      sync.Mutex
+	// live is the connection known to be up, guarded by its own lock so
+	// that the state can be read while a Send is reconnecting
+	live   *websocket.Conn
+	liveMu sync.Mutex
 }
 
+// NewSession connects the session, blocking until the proxy is reachable.
 func (s *Session) NewSession() *Session {
-	u := url.URL{Scheme: "ws", Host: s.Host, Path: fmt.Sprintf("/%s", s.Channel)}
-	log.Printf("connecting to %s", u.String())
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
-	if err != nil {
-		log.Fatal("dial:", err)
-		os.Exit(0)
-	}
-	s.Conn = conn
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connect(context.Background())
 	return s
 }
 
+// Connect connects the session unless it is already connected, retrying
+// until the proxy is reachable or the context is done.
+func (s *Session) Connect(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Conn != nil {
+		return nil
+	}
+	return s.connect(ctx)
+}
+
+// connect dials the proxy, retrying until it succeeds or the context is
+// done.
+func (s *Session) connect(ctx context.Context) error {
+	scheme, dialer := s.Scheme, s.Dialer
+	if scheme == "" {
+		scheme = "ws"
+	}
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+	u := url.URL{Scheme: scheme, Host: s.Host, Path: fmt.Sprintf("/%s", s.Channel)}
+	backoff := NewBackoff()
+	for {
+		log.Printf("connecting to %s", u.String())
+		conn, _, err := dialer.Dial(u.String(), nil)
+		if err == nil {
+			s.Conn = conn
+			s.setLive(conn)
+			go s.readLoop(conn)
+			return nil
+		}
+		delay := backoff.Step()
+		logrus.Errorf("dial %s: %v, retrying in %s", u.String(), err, delay)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// readLoop consumes control frames and closes the connection as soon as the
+// proxy goes away, so the next Send reconnects instead of writing into a
+// dead socket.
+func (s *Session) readLoop(conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.NextReader(); err != nil {
+			logrus.Warnf("%s session closed: %v", s.Channel, err)
+			s.dropLive(conn)
+			conn.Close()
+			return
+		}
+	}
+}
+
+// Send writes a message, connecting first if needed. On failure the
+// connection is dropped and the error returned; the next call reconnects.
 func (s *Session) Send(message []byte) error {
+	return s.SendContext(context.Background(), message)
+}
+
+// SendContext is Send giving up on connecting once the context is done.
+func (s *Session) SendContext(ctx context.Context, message []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+	s.Conn.SetWriteDeadline(time.Now().Add(sessionWriteTimeout))
+	err := s.Conn.WriteMessage(websocket.TextMessage, message)
+	if err != nil {
+		s.dropLive(s.Conn)
+		s.Conn.Close()
+		s.Conn = nil
+	}
+	return err
+}
+
+// Close tells the proxy the session is closing and closes the connection.
+func (s *Session) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.Conn.WriteMessage(websocket.TextMessage, message)
+	if s.Conn == nil {
+		return nil
+	}
+	s.Conn.SetWriteDeadline(time.Now().Add(sessionWriteTimeout))
+	s.Conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	s.dropLive(s.Conn)
+	err := s.Conn.Close()
+	s.Conn = nil
+	return err
+}
+
+// Connected tells whether the session is connected to the proxy.
+func (s *Session) Connected() bool {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+	return s.live != nil
+}
+
+func (s *Session) setLive(conn *websocket.Conn) {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+	s.live = conn
+}
+
+func (s *Session) dropLive(conn *websocket.Conn) {
+	s.liveMu.Lock()
+	defer s.liveMu.Unlock()
+	if s.live == conn {
+		s.live = nil
+	}
 }
@@ -0,0 +1,19 @@
+package utils
+
+import "context"
+
+type shuttingDownKey struct{}
+
+// WithShuttingDown returns a context telling the handlers of the requests
+// served under it when the server starts shutting down. The server waits
+// for running requests on shutdown, so streams have to end on their own.
+func WithShuttingDown(parent context.Context, shuttingDown <-chan struct{}) context.Context {
+	return context.WithValue(parent, shuttingDownKey{}, shuttingDown)
+}
+
+// ShuttingDown returns the channel closed when the server serving the
+// request starts shutting down, nil outside of such a server.
+func ShuttingDown(ctx context.Context) <-chan struct{} {
+	shuttingDown, _ := ctx.Value(shuttingDownKey{}).(<-chan struct{})
+	return shuttingDown
+}